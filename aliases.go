@@ -0,0 +1,134 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ", ") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// aliasData is what an -alias-template sees.
+type aliasData struct {
+	Year, Month, Day string
+	Slug             string // output slug (YYYY-MM-name)
+	Name             string // post name without the date prefix
+	Path             string // path of the original permalink
+}
+
+func parseAliasTemplates(srcs []string) ([]*template.Template, error) {
+	var out []*template.Template
+	for _, src := range srcs {
+		t, err := template.New("alias").Option("missingkey=error").Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// buildAliases appends the alias templates' output to the existing aliases,
+// normalized to absolute paths with a trailing slash and deduplicated.
+func buildAliases(aliases []string, tmpls []*template.Template, data aliasData) ([]string, error) {
+	seen := make(map[string]bool, len(aliases))
+	for _, a := range aliases {
+		seen[a] = true
+	}
+	for _, t := range tmpls {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		a := strings.TrimSpace(buf.String())
+		if a == "" {
+			continue
+		}
+		a = ensureTrailingSlash(path.Clean("/" + a))
+		if !seen[a] {
+			seen[a] = true
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases, nil
+}
+
+// aliasVariants adds each alias without its trailing slash (-alias-unslashed),
+// since WordPress answered both forms. Duplicates are dropped.
+func (c *Converter) aliasVariants(aliases []string) []string {
+	seen := make(map[string]bool, len(aliases))
+	var out []string
+	add := func(a string) {
+		if a != "" && a != "/" && !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	for _, a := range aliases {
+		add(a)
+		if c.opts.AliasUnslashed {
+			add(strings.TrimSuffix(a, "/"))
+		}
+	}
+	return out
+}
+
+// shortlink is the /?p=ID short link of a post for -alias-shortlink, when
+// guid is one. It is not a Hugo alias: Hugo would publish it as a
+// "?p=ID/index.html" file no query string request reaches, so only
+// -redirects and -urlmap use it.
+func (c *Converter) shortlink(guid string) string {
+	if !c.opts.AliasShortlink {
+		return ""
+	}
+	if id := shortlinkID(guid); id != "" {
+		return "/?p=" + id
+	}
+	return ""
+}
+
+// shortlinkID is the post ID of a WordPress ?p=ID permalink, the GUID
+// WordPress gives every post (https://example.com/?p=123).
+func shortlinkID(guid string) string {
+	u, err := url.Parse(strings.TrimSpace(guid))
+	if err != nil || (u.Path != "" && u.Path != "/" && u.Path != "/index.php") {
+		return ""
+	}
+	id := u.Query().Get("p")
+	if n, err := strconv.Atoi(id); err != nil || n <= 0 {
+		return ""
+	}
+	return id
+}
+
+// claimAliases registers the aliases for the post in file and drops those
+// already claimed by an earlier post, with a warning (an error under -strict).
+func (c *Converter) claimAliases(aliases []string, file string) ([]string, error) {
+	if c.aliasOwners == nil {
+		return aliases, nil
+	}
+	kept := aliases[:0:0]
+	for _, a := range aliases {
+		owner, taken := c.aliasOwners[a]
+		switch {
+		case !taken || owner == file:
+			kept = append(kept, a)
+		case c.opts.Strict:
+			return nil, fmt.Errorf("alias %s already used by %s", a, owner)
+		default:
+			c.warnf("alias %s already used by %s, dropped from %s", a, owner, file)
+		}
+	}
+	for _, a := range kept {
+		c.aliasOwners[a] = file
+	}
+	return kept, nil
+}
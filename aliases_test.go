@@ -0,0 +1,127 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAliasTemplates(t *testing.T) {
+	tmpls, err := parseAliasTemplates([]string{"/blog/{{.Name}}/", "archive/{{.Year}}/{{.Name}}", "{{.Path}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := conv.aliasTemplates
+	conv.aliasTemplates = tmpls
+	t.Cleanup(func() { conv.aliasTemplates = old })
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+
+	item := Item{Title: "Post", Link: "https://example.com/2024/03/05/my-post/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000"}
+	rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "aliases:\n    - /2024/03/05/my-post/\n    - /blog/my-post/\n    - /archive/2024/my-post/\n"
+	if !strings.Contains(string(data), want) {
+		t.Errorf("got\n%s\nwant it to contain\n%s", data, want)
+	}
+
+	if _, err := parseAliasTemplates([]string{"/{{.Nope"}); err == nil {
+		t.Error("invalid template parsed without error")
+	}
+}
+
+func TestAliasCollisions(t *testing.T) {
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	tmpls, err := parseAliasTemplates([]string{"/blog/{{.Name}}/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldTmpls, oldOwners := conv.aliasTemplates, conv.aliasOwners
+	t.Cleanup(func() { conv.aliasTemplates, conv.aliasOwners = oldTmpls, oldOwners })
+	conv.aliasTemplates = tmpls
+
+	// both posts are called "hello", so both want /blog/hello/
+	first := Item{Title: "Hello", Link: "https://example.com/2023/05/01/hello/"}
+	second := Item{Title: "Hello again", Link: "https://example.com/2024/03/05/hello/"}
+	for _, strict := range []string{"false", "true"} {
+		t.Run("strict="+strict, func(t *testing.T) {
+			setFlag(t, "strict", strict)
+			conv.aliasOwners = map[string]string{}
+			if _, err := conv.processItem(first, time.UTC, conv.newDownloader(1, 1)); err != nil {
+				t.Fatal(err)
+			}
+			rec, err := conv.processItem(second, time.UTC, conv.newDownloader(1, 1))
+			if strict == "true" {
+				if err == nil {
+					t.Error("colliding alias accepted under -strict")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(rec.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "aliases:\n    - /2024/03/05/hello/\n---"; !strings.Contains(string(data), want) {
+				t.Errorf("got\n%s\nwant it to contain\n%s", data, want)
+			}
+		})
+	}
+}
+
+func TestAliasVariants(t *testing.T) {
+	aliases := []string{"/2024/03/title/", "/blog/title/", "/2024/03/title/"}
+	tests := []struct {
+		unslashed string
+		want      []string
+	}{
+		{"false", []string{"/2024/03/title/", "/blog/title/"}},
+		{"true", []string{"/2024/03/title/", "/2024/03/title", "/blog/title/", "/blog/title"}},
+	}
+	for _, tt := range tests {
+		setFlag(t, "alias-unslashed", tt.unslashed)
+		got := conv.aliasVariants(aliases)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("unslashed=%s: got %q, want %q", tt.unslashed, got, tt.want)
+		}
+	}
+}
+
+func TestShortlink(t *testing.T) {
+	tests := []struct {
+		flag, guid, want string
+	}{
+		{"false", "https://example.com/?p=42", ""},
+		{"true", "https://example.com/?p=42", "/?p=42"},
+		{"true", "https://example.com/2024/03/title/", ""},
+		{"true", "https://example.com/?p=abc", ""},
+	}
+	for _, tt := range tests {
+		setFlag(t, "alias-shortlink", tt.flag)
+		if got := conv.shortlink(tt.guid); got != tt.want {
+			t.Errorf("-alias-shortlink=%s %s: got %q, want %q", tt.flag, tt.guid, got, tt.want)
+		}
+	}
+
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	item := Item{Title: "Title", Link: "https://example.com/2024/03/05/title/", GUID: "https://example.com/?p=42",
+		PubDate: "Tue, 05 Mar 2024 10:00:00 +0000", Description: "Body"}
+	rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(rec.File); strings.Contains(string(data), "?p=") || rec.Shortlink != "/?p=42" {
+		t.Errorf("shortlink %q, page:\n%s", rec.Shortlink, data)
+	}
+}
@@ -0,0 +1,112 @@
+package wordpress2hugo
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// feedBase is what relative URLs in a feed resolve against: the feed's own
+// URL when it was fetched, else the site link it names. "" for neither.
+func feedBase(src, siteLink string) string {
+	for _, s := range []string{src, siteLink} {
+		if u := absHTTPURL(s); u != nil {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// absHTTPURL parses s as an absolute http(s) URL, or returns nil.
+func absHTTPURL(s string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// resolveItemURLs makes the links, covers and enclosures of items absolute
+// and records the feed base for items without a link of their own.
+func resolveItemURLs(items []Item, base string) {
+	b := absHTTPURL(base)
+	for i := range items {
+		it := &items[i]
+		it.BaseURL = base
+		it.Link = resolveURL(b, it.Link)
+		ib := itemBase(*it)
+		it.Cover = resolveURL(ib, it.Cover)
+		for j := range it.Enclosures {
+			it.Enclosures[j].URL = resolveURL(ib, it.Enclosures[j].URL)
+		}
+	}
+}
+
+// itemBase is the URL the item's content is relative to: its link, else the
+// feed's base. nil when neither is an absolute http(s) URL.
+func itemBase(item Item) *url.URL {
+	if u := absHTTPURL(item.Link); u != nil {
+		return u
+	}
+	return absHTTPURL(item.BaseURL)
+}
+
+// resolveURL resolves ref against base. Absolute URLs, fragment-only links
+// and anything without a base are returned unchanged.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveSrcset resolves each candidate URL of a srcset, keeping its width
+// or density descriptor.
+func resolveSrcset(base *url.URL, srcset string) string {
+	if strings.HasPrefix(strings.TrimSpace(srcset), "data:") {
+		return srcset // commas inside the data URI would split it
+	}
+	parts := strings.Split(srcset, ",")
+	for i, p := range parts {
+		f := strings.Fields(p)
+		if len(f) == 0 {
+			continue
+		}
+		f[0] = resolveURL(base, f[0])
+		parts[i] = strings.Join(f, " ")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resolveRelativeURLs makes the media URLs in the post (src, srcset and
+// poster attributes, and links around images to a full-size image) absolute
+// against base, so relative media paths (common in Atom feeds) download from
+// where they were published. Other links stay as they are: a root-relative
+// /about/ keeps working on the new site.
+func resolveRelativeURLs(doc *goquery.Document, base *url.URL) {
+	if base == nil {
+		return
+	}
+	for _, attr := range []string{"src", "poster"} {
+		doc.Find("[" + attr + "]").Each(func(_ int, s *goquery.Selection) {
+			s.SetAttr(attr, resolveURL(base, s.AttrOr(attr, "")))
+		})
+	}
+	doc.Find("[srcset]").Each(func(_ int, s *goquery.Selection) {
+		s.SetAttr("srcset", resolveSrcset(base, s.AttrOr("srcset", "")))
+	})
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if a.Find("img").Length() == 0 {
+			return
+		}
+		if href := resolveURL(base, a.AttrOr("href", "")); isRemoteImage(href) {
+			a.SetAttr("href", href)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveURL(t *testing.T) {
+	base := absHTTPURL("https://example.com/blog/2024/03/post/")
+	tests := []struct{ in, want string }{
+		{"img/a.png", "https://example.com/blog/2024/03/post/img/a.png"},
+		{"../b.jpg", "https://example.com/blog/2024/03/b.jpg"},
+		{"/uploads/c.jpg", "https://example.com/uploads/c.jpg"},
+		{"//cdn.example.net/d.jpg", "https://cdn.example.net/d.jpg"},
+		{"https://other.example/e.jpg", "https://other.example/e.jpg"},
+		{"#note-1", "#note-1"},
+		{"mailto:jane@example.com", "mailto:jane@example.com"},
+		{"data:image/gif;base64,R0lGOD", "data:image/gif;base64,R0lGOD"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveURL(base, tt.in); got != tt.want {
+			t.Errorf("resolveURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := resolveURL(nil, "img/a.png"); got != "img/a.png" {
+		t.Errorf("without a base: %q", got)
+	}
+	if got, want := resolveSrcset(base, "a-300.png 300w, /a-1024.png 1024w"),
+		"https://example.com/blog/2024/03/post/a-300.png 300w, https://example.com/a-1024.png 1024w"; got != want {
+		t.Errorf("resolveSrcset = %q, want %q", got, want)
+	}
+}
+
+func TestAtomRelativeURLs(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/feed.atom" {
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>`+
+				`<entry><title>Post</title><link rel="alternate" href="2024/03/05/post/"/><id>urn:post</id>`+
+				`<published>2024-03-05T10:00:00Z</published><updated>2024-03-05T10:00:00Z</updated>`+
+				`<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>See <a href="/about/">this</a>.</p>`+
+				`<p><a href="img/a-full.png"><img src="img/a.png" alt="A"/></a></p><p><img src="/uploads/b.png" alt="B"/></p></div></content></entry></feed>`)
+			return
+		}
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+	setFlag(t, "content-format", "html")
+
+	rss, err := conv.loadRSS(srv.URL + "/feed.atom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := rss.Channel.Items[0]
+	if want := srv.URL + "/2024/03/05/post/"; item.Link != want {
+		t.Errorf("link = %q, want %q", item.Link, want)
+	}
+	dl := conv.newDownloader(2, 2)
+	rec, err := conv.processItem(item, time.UTC, dl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.Wait()
+	if f := dl.Failures(); len(f) > 0 {
+		t.Fatalf("failures: %v", f)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		`<a href="/about/">`,
+		`<a href="/media/2024-03-post/002_a-full.png"><img src="/media/2024-03-post/001_a.png"`,
+		`<img src="/media/2024-03-post/003_b.png"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("post does not contain %q:\n%s", want, got)
+		}
+	}
+	for _, want := range []string{"/2024/03/05/post/img/a.png", "/2024/03/05/post/img/a-full.png", "/uploads/b.png"} {
+		found := false
+		for _, p := range paths {
+			found = found || p == want
+		}
+		if !found {
+			t.Errorf("%s not requested (got %v)", want, paths)
+		}
+	}
+	if !fileExists(filepath.Join(static, "media", "2024-03-post", "001_a.png")) {
+		t.Errorf("relative image not downloaded")
+	}
+}
@@ -0,0 +1,33 @@
+package wordpress2hugo
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// mediaDir is the folder the media of a post are saved to, name being the
+// post's media name (see processItem): static/media/<name>, static/media with
+// -flatten-images, or with -bundles the post's own bundle folder next to its
+// index.md.
+func (c *Converter) mediaDir(name string) string {
+	if c.opts.Bundles {
+		return filepath.Join(c.opts.Out, filepath.FromSlash(name))
+	}
+	if c.flatMedia != nil {
+		return filepath.Join(c.opts.Static, "media")
+	}
+	return filepath.Join(c.opts.Static, "media", name)
+}
+
+// mediaURL is the prefix of the rewritten src attributes: /media/<name>,
+// /media with -flatten-images, or empty with -bundles, where the page
+// references its resources relatively.
+func (c *Converter) mediaURL(name string) string {
+	if c.opts.Bundles {
+		return ""
+	}
+	if c.flatMedia != nil {
+		return "/media"
+	}
+	return path.Join("/media", name)
+}
@@ -0,0 +1,55 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBundles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+	out := t.TempDir()
+	setFlag(t, "out", out)
+	setFlag(t, "static", t.TempDir())
+	setFlag(t, "bundles", "true")
+	setFlag(t, "v", "false")
+
+	item := Item{
+		Title:          "Post",
+		Link:           "https://example.com/2024/03/05/post/",
+		PubDate:        "Tue, 05 Mar 2024 10:00:00 +0000",
+		ContentEncoded: `<p>Hello</p><img src="` + srv.URL + `/photo.png">`,
+	}
+	dl := conv.newDownloader(1, 1)
+	rec, err := conv.processItem(item, time.UTC, dl)
+	dl.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(out, "2024-03-post")
+	if want := filepath.Join(dir, "index.md"); rec.File != want {
+		t.Errorf("file = %s, want %s", rec.File, want)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "001_photo.png")); err != nil || string(got) != "png" {
+		t.Errorf("image = %q, %v", got, err)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "](001_photo.png)") {
+		t.Errorf("image not referenced relative to the bundle:\n%s", data)
+	}
+	if got := conv.pagePath(rec.File); got != "/2024-03-post/" {
+		t.Errorf("pagePath = %q", got)
+	}
+}
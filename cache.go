@@ -0,0 +1,107 @@
+package wordpress2hugo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diskCache keeps a copy of every downloaded file outside the site, named
+// by the SHA-256 of its URL (plus the file's extension), so later runs copy
+// it instead of downloading it again, even after -clean.
+type diskCache struct {
+	dir           string
+	maxImageBytes int64 // -max-image-bytes
+}
+
+func newDiskCache(dir string, maxImageBytes int64) *diskCache {
+	if dir == "" {
+		return nil
+	}
+	return &diskCache{dir: dir, maxImageBytes: maxImageBytes}
+}
+
+func (c *diskCache) key(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	k := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, k[:2], k)
+}
+
+// fetch copies the cached file of rawURL to dest, which gets the cached
+// extension when it has none. It returns the final dest and the file's
+// SHA-256, and ok=false on a miss; err says why a cached file could not be
+// copied.
+func (c *diskCache) fetch(rawURL, dest string) (string, string, bool, error) {
+	if c == nil {
+		return dest, "", false, nil
+	}
+	matches, _ := filepath.Glob(c.key(rawURL) + "*")
+	cached := ""
+	for _, m := range matches {
+		if !strings.HasSuffix(m, ".part") {
+			cached = m
+			break
+		}
+	}
+	if cached == "" {
+		return dest, "", false, nil
+	}
+	if filepath.Ext(dest) == "" {
+		dest += filepath.Ext(cached)
+	}
+	if c.maxImageBytes > 0 && strings.HasPrefix(mime.TypeByExtension(filepath.Ext(cached)), "image/") {
+		if st, err := os.Stat(cached); err == nil && st.Size() > c.maxImageBytes {
+			return dest, "", false, nil // over the limit now; the download decides
+		}
+	}
+	sum, err := copyFile(cached, dest)
+	if err != nil {
+		return dest, "", false, err
+	}
+	return dest, sum, true, nil
+}
+
+// store adds the downloaded file src of rawURL to the cache.
+func (c *diskCache) store(rawURL, src string) error {
+	if c == nil {
+		return nil
+	}
+	_, err := copyFile(src, c.key(rawURL)+filepath.Ext(src))
+	return err
+}
+
+// copyFile copies src to dest through a .part file and returns the SHA-256
+// of the content.
+func copyFile(src, dest string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", err
+	}
+	part := dest + ".part"
+	out, err := os.Create(part)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, h), in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(part, dest)
+	}
+	if err != nil {
+		os.Remove(part)
+		return "", fmt.Errorf("copy %s: %w", src, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,50 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMediaCache(t *testing.T) {
+	setFlag(t, "v", "false")
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png "+r.URL.Path)
+	}))
+	defer srv.Close()
+	conv.mediaCache = newDiskCache(t.TempDir(), 0)
+	t.Cleanup(func() { conv.mediaCache = nil })
+
+	// the first run downloads, the second one (after -clean) copies from the cache
+	var sums []string
+	for run := 0; run < 2; run++ {
+		dir := t.TempDir()
+		for _, name := range []string{"a.png", "cdn"} {
+			dest, sum, err := conv.downloadFile(srv.URL+"/"+name, filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name == "cdn" && filepath.Ext(dest) != ".png" {
+				t.Errorf("run %d: extensionless dest = %s, want .png from the response", run, dest)
+			}
+			data, err := os.ReadFile(dest)
+			if err != nil || string(data) != "png /"+name {
+				t.Errorf("run %d: %s = %q, %v", run, dest, data, err)
+			}
+			sums = append(sums, sum)
+		}
+	}
+	if hits.Load() != 2 {
+		t.Errorf("%d requests, want 2 (one per URL)", hits.Load())
+	}
+	if sums[0] != sums[2] || sums[1] != sums[3] || sums[0] == "" {
+		t.Errorf("checksums differ between download and cache: %q", sums)
+	}
+}
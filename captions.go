@@ -0,0 +1,63 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WordPress [caption] shortcodes (left unrendered in WXR exports):
+// [caption id="…" align="…" width="300"]<a …><img …></a> Caption text[/caption]
+var (
+	captionShortcodeRe = regexp.MustCompile(`(?s)\[caption([^\]]*)\](.*?)\[/caption\]`)
+	captionMediaRe     = regexp.MustCompile(`(?s)^\s*((?:<a\b[^>]*>\s*)?<img\b[^>]*>(?:\s*</a>)?)(.*)$`)
+	captionAttrRe      = regexp.MustCompile(`\bcaption="([^"]*)"`)
+)
+
+// expandCaptionShortcodes rewrites [caption] shortcodes into the
+// <figure class="wp-caption"><figcaption> markup WordPress renders for them.
+// Shortcodes without an image are left alone.
+func expandCaptionShortcodes(html string) string {
+	return captionShortcodeRe.ReplaceAllStringFunc(html, func(sc string) string {
+		m := captionShortcodeRe.FindStringSubmatch(sc)
+		parts := captionMediaRe.FindStringSubmatch(m[2])
+		if parts == nil {
+			return sc
+		}
+		caption := strings.TrimSpace(parts[2])
+		if caption == "" {
+			// very old posts keep the text in a caption="" attribute
+			if a := captionAttrRe.FindStringSubmatch(m[1]); a != nil {
+				caption = strings.TrimSpace(a[1])
+			}
+		}
+		if caption == "" {
+			return parts[1]
+		}
+		return `<figure class="wp-caption">` + parts[1] + `<figcaption>` + caption + `</figcaption></figure>`
+	})
+}
+
+// figureShortcode renders a <figure> with an image and a <figcaption> as
+// Hugo's {{< figure >}} shortcode. ok is false for other figures.
+func figureShortcode(fig *goquery.Selection) (string, bool) {
+	img := fig.Find("img").First()
+	caption := strings.Join(strings.Fields(fig.Find("figcaption").First().Text()), " ")
+	src := strings.TrimSpace(img.AttrOr("src", ""))
+	if img.Length() == 0 || src == "" || caption == "" {
+		return "", false
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "{{< figure src=%s", strconv.Quote(src))
+	if alt := strings.TrimSpace(img.AttrOr("alt", "")); alt != "" {
+		fmt.Fprintf(&b, " alt=%s", strconv.Quote(alt))
+	}
+	if href := strings.TrimSpace(img.ParentsFiltered("a").First().AttrOr("href", "")); href != "" && href != src {
+		fmt.Fprintf(&b, " link=%s", strconv.Quote(href))
+	}
+	fmt.Fprintf(&b, " caption=%s >}}", strconv.Quote(caption))
+	return b.String(), true
+}
@@ -0,0 +1,46 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestCaptionsToFigureShortcode(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"figcaption",
+			`<figure class="wp-caption"><img src="/media/s/001_a.jpg" alt="A dog"><figcaption>Our dog, "Rex"</figcaption></figure>`,
+			`{{< figure src="/media/s/001_a.jpg" alt="A dog" caption="Our dog, \"Rex\"" >}}`,
+		},
+		{
+			"figcaption with link",
+			`<p>Before</p><figure class="wp-block-image"><a href="https://example.com/"><img src="/media/s/001_a.jpg"></a><figcaption>Linked  <em>caption</em></figcaption></figure>`,
+			"Before\n\n" + `{{< figure src="/media/s/001_a.jpg" link="https://example.com/" caption="Linked caption" >}}`,
+		},
+		{
+			"caption shortcode",
+			`[caption id="attachment_7" align="alignnone" width="300"]<a href="/media/s/001_a.jpg"><img src="/media/s/001_a.jpg" alt="Alt" /></a> Sunset at the lake[/caption]`,
+			`{{< figure src="/media/s/001_a.jpg" alt="Alt" caption="Sunset at the lake" >}}`,
+		},
+		{
+			"caption attribute",
+			`<p>[caption id="" align="alignleft" width="200" caption="Old style"]<img src="/media/s/001_a.jpg">[/caption]</p>`,
+			`{{< figure src="/media/s/001_a.jpg" caption="Old style" >}}`,
+		},
+		{
+			"figure without caption",
+			`<figure><img src="/media/s/001_a.jpg" alt="x"></figure>`,
+			`![x](/media/s/001_a.jpg)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := conv.toMarkdownPreserveOrder(tt.in, "s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WPCategory is a channel-level <wp:category> of a WXR export. Only exports
+// carry these; they are the only place the category hierarchy is recorded.
+type WPCategory struct {
+	Nicename string `xml:"category_nicename"`
+	Parent   string `xml:"category_parent"` // nicename of the parent, empty for top-level
+	Name     string `xml:"cat_name"`
+}
+
+// categoryPaths maps each category name to its chain of names from the root
+// down to itself. Broken parent links end the chain; cycles are cut.
+func categoryPaths(cats []WPCategory) map[string][]string {
+	byNicename := make(map[string]WPCategory, len(cats))
+	for _, c := range cats {
+		if c.Nicename = strings.TrimSpace(c.Nicename); c.Nicename != "" {
+			byNicename[c.Nicename] = c
+		}
+	}
+	out := make(map[string][]string, len(byNicename))
+	for _, c := range byNicename {
+		name := strings.TrimSpace(htmlUnescape(c.Name))
+		if name == "" {
+			continue
+		}
+		chain := []string{name}
+		seen := map[string]bool{c.Nicename: true}
+		for p, ok := byNicename[strings.TrimSpace(c.Parent)]; ok && !seen[p.Nicename]; p, ok = byNicename[strings.TrimSpace(p.Parent)] {
+			seen[p.Nicename] = true
+			chain = append([]string{strings.TrimSpace(htmlUnescape(p.Name))}, chain...)
+		}
+		out[name] = chain
+	}
+	return out
+}
+
+// categoryPathTerms replaces each category by its slash-joined path
+// ("Parent/Child"); categories without a known hierarchy stay as they are.
+func categoryPathTerms(cats []string, paths map[string][]string) []string {
+	m := map[string]struct{}{}
+	for _, c := range cats {
+		if p, ok := paths[c]; ok {
+			c = strings.Join(p, "/")
+		}
+		m[c] = struct{}{}
+	}
+	return setToSortedSlice(m)
+}
+
+// categorySection picks the deepest category of the item as its section path,
+// or nil when none of its categories is nested.
+func categorySection(cats []string, paths map[string][]string) []string {
+	var best []string
+	for _, c := range cats {
+		if p := paths[c]; len(p) > len(best) {
+			best = p
+		}
+	}
+	if len(best) < 2 {
+		return nil
+	}
+	return best
+}
+
+// ensureSectionIndexes writes an _index.md for every level of a nested
+// section below outDir, since Hugo only treats directories with one as
+// sections. Existing files are left alone. It returns the section directory
+// and the _index.md files that are the tool's: written now, or left as an
+// earlier run wrote them, so -clean can tell them from hand-made ones.
+func (c *Converter) ensureSectionIndexes(outDir string, section []string) (dir string, indexes []string, err error) {
+	for _, name := range section {
+		dir = path.Join(dir, c.slugify(name))
+		idx := filepath.Join(outDir, filepath.FromSlash(dir), "_index.md")
+		own, err := c.writeSectionIndex(idx, name)
+		if err != nil {
+			return "", nil, err
+		}
+		if own {
+			indexes = append(indexes, idx)
+		}
+	}
+	return dir, indexes, nil
+}
+
+// writeSectionIndex writes idx with just a title unless the file exists. It
+// reports whether idx is the tool's: written now, or still as generated.
+func (c *Converter) writeSectionIndex(idx, title string) (own bool, err error) {
+	fm := newFMMap()
+	fm.Set("title", title)
+	data, err := c.marshalFrontMatter(fm)
+	if err != nil {
+		return false, err
+	}
+	if old, err := os.ReadFile(idx); err == nil {
+		return bytes.Equal(old, data), nil
+	}
+	if c.dryRun != nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(idx), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(idx, data, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// categoryAllowed applies -exclude-categories and -include-categories to a
+// category name.
+func (c *Converter) categoryAllowed(name string) bool {
+	if inNameList(c.opts.ExcludeCategories, name) {
+		return false
+	}
+	return c.opts.IncludeCategories == "" || inNameList(c.opts.IncludeCategories, name)
+}
+
+// onlyExcludedCategories reports whether the item has categories and all of
+// them are filtered out (tags and post formats don't count).
+func (c *Converter) onlyExcludedCategories(cats []Category) bool {
+	n := 0
+	for _, cat := range cats {
+		name := strings.TrimSpace(htmlUnescape(cat.Value))
+		if name == "" || cat.Domain == "post_format" || strings.EqualFold(cat.Domain, "post_tag") {
+			continue
+		}
+		if c.categoryAllowed(name) {
+			return false
+		}
+		n++
+	}
+	return n > 0
+}
+
+// inNameList reports whether name is in the comma-separated list, ignoring case.
+func inNameList(list, name string) bool {
+	for _, v := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCategoryHierarchy(t *testing.T) {
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/"><channel>` +
+		`<wp:category><wp:category_nicename>reisen</wp:category_nicename><wp:category_parent></wp:category_parent><wp:cat_name><![CDATA[Reisen]]></wp:cat_name></wp:category>` +
+		`<wp:category><wp:category_nicename>italien</wp:category_nicename><wp:category_parent>reisen</wp:category_parent><wp:cat_name><![CDATA[Italien]]></wp:cat_name></wp:category>` +
+		`<item><title>Rom</title><link>https://example.com/2024/03/05/rom/</link><guid>g1</guid>` +
+		`<category domain="category" nicename="italien"><![CDATA[Italien]]></category>` +
+		`<category domain="category" nicename="essen"><![CDATA[Essen]]></category>` +
+		`<description>Body</description></item></channel></rss>`
+
+	tests := []struct {
+		mode     string
+		file     string
+		wantCats string
+	}{
+		{"flat", "2024-03-rom.md", "categories:\n    - Essen\n    - Italien\n"},
+		{"path", "2024-03-rom.md", "categories:\n    - Essen\n    - Reisen/Italien\n"},
+		{"section", "reisen/italien/2024-03-rom.md", "categories:\n    - Essen\n    - Italien\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			dir := t.TempDir()
+			setFlag(t, "out", dir)
+			setFlag(t, "v", "false")
+			setFlag(t, "category-hierarchy", tt.mode)
+			feedPath := filepath.Join(dir, "feed.xml")
+			if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			rss, err := conv.loadRSS(feedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := conv.processItem(rss.Channel.Items[0], time.UTC, conv.newDownloader(1, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := filepath.Rel(dir, rec.File); filepath.ToSlash(got) != tt.file {
+				t.Errorf("file = %s, want %s", got, tt.file)
+			}
+			data, err := os.ReadFile(rec.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.wantCats) {
+				t.Errorf("got\n%s\nwant it to contain\n%s", data, tt.wantCats)
+			}
+			if tt.mode == "section" {
+				for _, idx := range []string{"reisen/_index.md", "reisen/italien/_index.md"} {
+					if !fileExists(filepath.Join(dir, idx)) {
+						t.Errorf("missing %s", idx)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCategoryFilters(t *testing.T) {
+	cats := []Category{
+		{Value: "Allgemein"}, {Value: "Reisen"}, {Value: "Kochen"},
+		{Domain: "post_tag", Value: "allgemein"}, {Domain: "post_format", Value: "Gallery"},
+	}
+	tests := []struct {
+		exclude, include string
+		wantCats         string
+	}{
+		{"Allgemein", "", "Kochen|Reisen"},
+		{"", "", "Allgemein|Kochen|Reisen"},
+		{"allgemein, kochen", "", "Reisen"},
+		{"Allgemein", "reisen,Sport", "Reisen"},
+	}
+	for _, tt := range tests {
+		setFlag(t, "exclude-categories", tt.exclude)
+		setFlag(t, "include-categories", tt.include)
+		tags, got := conv.splitTagsAndCategories(cats)
+		if strings.Join(got, "|") != tt.wantCats {
+			t.Errorf("exclude=%q include=%q: categories = %q, want %s", tt.exclude, tt.include, got, tt.wantCats)
+		}
+		if len(tags) != 1 || tags[0] != "allgemein" {
+			t.Errorf("tags = %q, want the tag untouched", tags)
+		}
+	}
+
+	setFlag(t, "exclude-categories", "Allgemein,Intern")
+	setFlag(t, "include-categories", "")
+	for _, tt := range []struct {
+		cats []Category
+		want bool
+	}{
+		{[]Category{{Value: "Allgemein"}, {Value: "intern"}, {Domain: "post_tag", Value: "Go"}}, true},
+		{[]Category{{Value: "Allgemein"}, {Value: "Reisen"}}, false},
+		{[]Category{{Domain: "post_tag", Value: "Go"}}, false},
+		{nil, false},
+	} {
+		if got := conv.onlyExcludedCategories(tt.cats); got != tt.want {
+			t.Errorf("onlyExcludedCategories(%v) = %v, want %v", tt.cats, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksums writes a SHA256SUMS file (sha256sum -c compatible) for every
+// successfully downloaded file still on disk, with paths relative to the
+// file's directory. Call it after Wait.
+func (d *downloader) WriteChecksums(sumsPath string) error {
+	base := filepath.Dir(sumsPath)
+	sums := map[string]string{}
+	d.results.Range(func(_, v any) bool {
+		r := v.(dlResult)
+		if r.err != nil || r.sha256 == "" || !fileExists(r.dest) {
+			return true // failed, or replaced since (converted GIFs)
+		}
+		rel, err := filepath.Rel(base, r.dest)
+		if err != nil {
+			rel = r.dest
+		}
+		sums[filepath.ToSlash(rel)] = r.sha256
+		return true
+	})
+	names := make([]string, 0, len(sums))
+	for n := range sums {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, n := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[n], n)
+	}
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(sumsPath, buf.Bytes(), 0o644)
+}
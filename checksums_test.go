@@ -0,0 +1,54 @@
+package wordpress2hugo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteChecksums(t *testing.T) {
+	files := map[string]string{"/a.jpg": "first image", "/b.png": "second image"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	setFlag(t, "v", "false")
+
+	static := t.TempDir()
+	media := filepath.Join(static, "media", "2024-03-post")
+	// b.png is already on disk from an earlier run and is hashed from there
+	if err := os.MkdirAll(media, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(media, "002_b.png"), []byte("second image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dl := conv.newDownloader(2, 2)
+	dl.Schedule(srv.URL+"/a.jpg", filepath.Join(media, "001_a.jpg"))
+	dl.Schedule(srv.URL+"/b.png", filepath.Join(media, "002_b.png"))
+	dl.Schedule(srv.URL+"/gone.gif", filepath.Join(media, "003_gone.gif"))
+	dl.Wait()
+
+	sumsPath := filepath.Join(static, "SHA256SUMS")
+	if err := dl.WriteChecksums(sumsPath); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(sumsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%x  media/2024-03-post/001_a.jpg\n%x  media/2024-03-post/002_b.png\n",
+		sha256.Sum256([]byte("first image")), sha256.Sum256([]byte("second image")))
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package wordpress2hugo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cleanTargets sorts the files below dirs into those an earlier run wrote, as
+// listed in its -report manifest (posts, media, their thumbnails and section
+// _index.md files) or named in generated (pages such as -output-index that
+// the run writes again), and the foreign ones nobody recorded. Missing
+// directories hold no files.
+func cleanTargets(prev map[string]*postRecord, generated []string, dirs ...string) (own, foreign []string, err error) {
+	written := map[string]bool{}
+	mark := func(p string) {
+		if p == "" {
+			return
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			written[abs] = true
+		}
+	}
+	for _, rec := range prev {
+		mark(rec.File)
+		for _, a := range rec.Assets {
+			mark(a.Dest)
+			mark(thumbPath(a.Dest))
+		}
+		for _, idx := range rec.Indexes {
+			mark(idx)
+		}
+	}
+	for _, p := range generated {
+		mark(p)
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return filepath.SkipDir
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			if written[abs] {
+				own = append(own, p)
+			} else {
+				foreign = append(foreign, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	sort.Strings(own)
+	sort.Strings(foreign)
+	return own, foreign, nil
+}
+
+// removeFiles deletes files, then the directories below roots they leave
+// empty. The roots themselves stay.
+func removeFiles(files []string, roots ...string) error {
+	for _, f := range files {
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	for _, root := range roots {
+		var dirs []string
+		filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() && p != root {
+				dirs = append(dirs, p)
+			}
+			return nil
+		})
+		// deepest first, so a parent is empty once its children are gone
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+				if err := os.Remove(dirs[i]); err != nil {
+					return fmt.Errorf("remove %s: %w", dirs[i], err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cleanCounts describes files for the clean prompt and log: the post pages
+// (.md, .html) and the rest, which are media.
+func cleanCounts(files []string) string {
+	posts := 0
+	for _, f := range files {
+		switch strings.ToLower(filepath.Ext(f)) {
+		case ".md", ".markdown", ".html":
+			posts++
+		}
+	}
+	return fmt.Sprintf("%d posts and %d media files", posts, len(files)-posts)
+}
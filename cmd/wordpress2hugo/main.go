@@ -0,0 +1,13 @@
+// Command wordpress2hugo imports a WordPress feed into a Hugo site; see the
+// wordpress2hugo package for the flags and the library API.
+package main
+
+import (
+	"os"
+
+	"wordpress2hugo"
+)
+
+func main() {
+	os.Exit(wordpress2hugo.Main(os.Args[1:]))
+}
@@ -0,0 +1,87 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// codeLangAttrs carry the language on <pre>/<code> for some highlighter
+// plugins (Enlighter, Code Syntax Block, …).
+var codeLangAttrs = []string{"data-enlighter-language", "data-language", "data-lang", "lang"}
+
+// codeLangClassRe finds the language in a class attribute: language-python
+// and lang-python (Prism, highlight.js, Gutenberg), brush: python
+// (SyntaxHighlighter Evolved) and lang:python (Crayon).
+var codeLangClassRe = regexp.MustCompile(`(?:^|\s)(?:language-|lang-|lang:|brush:\s*)([A-Za-z0-9_+#.-]+)`)
+
+// codeLanguage returns the language hint of a code block, checking the <code>
+// before the <pre>. Placeholders like "plain" or "none" don't count.
+func codeLanguage(pre, code *goquery.Selection) string {
+	for _, s := range []*goquery.Selection{code, pre} {
+		if s.Length() == 0 {
+			continue
+		}
+		var lang string
+		for _, a := range codeLangAttrs {
+			if lang = s.AttrOr(a, ""); lang != "" {
+				break
+			}
+		}
+		if lang == "" {
+			if m := codeLangClassRe.FindStringSubmatch(s.AttrOr("class", "")); m != nil {
+				lang = m[1]
+			}
+		}
+		lang = strings.ToLower(strings.TrimRight(strings.TrimSpace(lang), ";"))
+		switch lang {
+		case "", "plain", "plaintext", "none", "default", "nohighlight":
+			continue
+		}
+		return lang
+	}
+	return ""
+}
+
+// codeBlock renders a <pre> as a fenced code block with its language. The
+// text is taken verbatim (entities decoded, <br> as newlines), and the fence
+// is longer than any backtick run inside.
+func codeBlock(pre *goquery.Selection) string {
+	code := pre.ChildrenFiltered("code").First()
+	src := pre
+	if code.Length() > 0 && pre.Children().Length() == 1 {
+		src = code
+	}
+	text := strings.TrimPrefix(preText(src), "\n")
+	text = strings.TrimRight(text, "\n")
+
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + codeLanguage(pre, code) + "\n" + text + "\n" + fence
+}
+
+// codePlaceholder stands in for the i-th code block during conversion.
+func codePlaceholder(i int) string {
+	return fmt.Sprintf("WP2HUGOCODEBLOCK%dX", i)
+}
+
+// preText is the text of s with <br> turned into newlines.
+func preText(s *goquery.Selection) string {
+	var b strings.Builder
+	s.Contents().Each(func(_ int, c *goquery.Selection) {
+		switch goquery.NodeName(c) {
+		case "#text":
+			b.WriteString(c.Text())
+		case "br":
+			b.WriteString("\n")
+		case "#comment":
+		default:
+			b.WriteString(preText(c))
+		}
+	})
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestCodeBlocks(t *testing.T) {
+	setFlag(t, "v", "false")
+	tests := []struct {
+		name, in, want string
+	}{
+		{"gutenberg language class", `<pre class="wp-block-code"><code class="language-python">def f(x):
+    return x &lt; 1 and "&amp;"
+</code></pre>`, "```python\ndef f(x):\n    return x < 1 and \"&\"\n```"},
+		{"highlight.js lang prefix", `<pre><code class="hljs lang-go">fmt.Println("*not* _markdown_")</code></pre>`, "```go\nfmt.Println(\"*not* _markdown_\")\n```"},
+		{"syntaxhighlighter brush", `<pre class="brush: php; gutter: false; title: ; notranslate">echo $a;</pre>`, "```php\necho $a;\n```"},
+		{"crayon", `<pre class="lang:js decode:true">let a = 1;</pre>`, "```js\nlet a = 1;\n```"},
+		{"enlighter attribute", `<pre class="EnlighterJSRAW" data-enlighter-language="Bash">ls -la</pre>`, "```bash\nls -la\n```"},
+		{"code lang attribute", `<pre class="wp-block-code"><code lang="rust">fn main() {}</code></pre>`, "```rust\nfn main() {}\n```"},
+		{"plain placeholder", `<pre class="brush: plain;">text</pre>`, "```\ntext\n```"},
+		{"no hint", `<pre>  indented
+	tabbed<br>after br</pre>`, "```\n  indented\n\ttabbed\nafter br\n```"},
+		{"backticks inside", "<pre><code class=\"language-md\">```go\nx\n```</code></pre>", "````md\n```go\nx\n```\n````"},
+	}
+	for _, tt := range tests {
+		got, err := conv.toMarkdownPreserveOrder(tt.in, "slug")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCodeBlockWhitespace(t *testing.T) {
+	setFlag(t, "v", "false")
+	got, err := conv.toMarkdownPreserveOrder("<p>Before</p><div><pre><code class=\"language-yaml\">a: 1\n\n\n\nb:   \n  - c</code></pre></div>", "slug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Before\n\n```yaml\na: 1\n\n\n\nb:   \n  - c\n```"; got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decodeFeedBody undoes compression the HTTP transport left in place: gzip,
+// found by its magic bytes (proxies compressing twice, a .gz file served as
+// is, a local feed.xml.gz), and a deflate Content-Encoding, which Go's
+// transport never decodes.
+func decodeFeedBody(data []byte, contentEncoding string) ([]byte, error) {
+	for bytes.HasPrefix(data, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(contentEncoding), "deflate") {
+		// zlib-wrapped per the spec, but some servers send raw DEFLATE
+		if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+			if out, err := io.ReadAll(zr); err == nil {
+				return out, nil
+			}
+		}
+		return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,84 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRSSCompressed(t *testing.T) {
+	setFlag(t, "v", "false")
+	feed := []byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Blog</title>` +
+		`<item><title>Post</title><link>https://example.com/post/</link><description><![CDATA[<img src="img/a.png">]]></description></item></channel></rss>`)
+	compress := func(w func(io.Writer) io.WriteCloser, data []byte) []byte {
+		var buf bytes.Buffer
+		zw := w(&buf)
+		zw.Write(data)
+		zw.Close()
+		return buf.Bytes()
+	}
+	gz := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	zl := func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+	fl := func(w io.Writer) io.WriteCloser { zw, _ := flate.NewWriter(w, flate.DefaultCompression); return zw }
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old/":
+			http.Redirect(w, r, "/new/feed/", http.StatusMovedPermanently)
+		case "/gzip-file":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(compress(gz, feed))
+		case "/double":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(compress(gz, compress(gz, feed)))
+		case "/deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+			w.Write(compress(zl, feed))
+		case "/raw-deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+			w.Write(compress(fl, feed))
+		default:
+			w.Write(feed)
+		}
+	}))
+	defer srv.Close()
+
+	local := filepath.Join(t.TempDir(), "feed.xml.gz")
+	if err := os.WriteFile(local, compress(gz, feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{srv.URL + "/gzip-file", srv.URL + "/double", srv.URL + "/deflate", srv.URL + "/raw-deflate", local} {
+		rss, err := conv.loadRSS(src)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if len(rss.Channel.Items) != 1 || rss.Channel.Items[0].Title != "Post" {
+			t.Errorf("%s: items = %+v", src, rss.Channel.Items)
+		}
+	}
+
+	// the move is logged, and relative URLs resolve against the new location
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	rss, err := conv.loadRSS(srv.URL + "/old/")
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "redirected to "+srv.URL+"/new/feed/") {
+		t.Errorf("redirect not logged: %q", logs.String())
+	}
+	if got, want := rss.Channel.Items[0].BaseURL, srv.URL+"/new/feed/"; got != want {
+		t.Errorf("base = %q, want %q", got, want)
+	}
+}
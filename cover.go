@@ -0,0 +1,66 @@
+package wordpress2hugo
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// coverExts are the file types taken as the cover with -cover-from-first-image.
+var coverExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true}
+
+// mediaImageURL is the item's media:thumbnail, else its first image
+// media:content, "" if it has neither.
+func mediaImageURL(exts ext.Extensions) string {
+	media := exts["media"]
+	for _, t := range media["thumbnail"] {
+		if u := strings.TrimSpace(t.Attrs["url"]); u != "" {
+			return u
+		}
+	}
+	for _, c := range media["content"] {
+		if strings.HasPrefix(c.Attrs["type"], "image/") || c.Attrs["medium"] == "image" {
+			if u := strings.TrimSpace(c.Attrs["url"]); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// postCover returns the site path of the post's cover image: the feed's
+// featured image (item.Cover), downloaded into the post's media folder unless
+// the body already uses it, or with -cover-from-first-image the first image of
+// the body. "" when there is none.
+func (c *Converter) postCover(item Item, mediaName string, dl *downloader, rec *postRecord) string {
+	dest := ""
+	if item.Cover != "" {
+		origURL := c.toOriginalURL(item.Cover)
+		for _, a := range rec.Assets {
+			if a.URL == origURL {
+				dest = a.Dest
+				break
+			}
+		}
+		if dest == "" {
+			dest = filepath.Join(c.mediaDir(mediaName), "cover_"+filenameFromURL(origURL))
+			if dest = c.scheduleMedia(dl, origURL, dest, true); dest == "" {
+				return "" // over -max-image-bytes
+			}
+			rec.addAsset(origURL, dest)
+		}
+	} else if c.opts.CoverFromFirstImage {
+		for _, a := range rec.Assets {
+			if coverExts[strings.ToLower(filepath.Ext(a.Dest))] {
+				dest = a.Dest
+				break
+			}
+		}
+	}
+	if dest == "" {
+		return ""
+	}
+	return path.Join(c.mediaURL(mediaName), filepath.Base(dest))
+}
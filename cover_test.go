@@ -0,0 +1,84 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMediaThumbnail(t *testing.T) {
+	feedPath := filepath.Join(t.TempDir(), "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/"><channel>` +
+		`<item><title>A</title><link>https://example.com/2024/03/05/a/</link><media:thumbnail url="https://example.com/a.jpg"/></item>` +
+		`<item><title>B</title><link>https://example.com/2024/03/05/b/</link><media:content url="https://example.com/b.mp4" type="video/mp4"/><media:content url="https://example.com/b.png" medium="image"/></item>` +
+		`<item><title>C</title><link>https://example.com/2024/03/05/c/</link></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, err := conv.loadRSS(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"https://example.com/a.jpg", "https://example.com/b.png", ""} {
+		if got := rss.Channel.Items[i].Cover; got != want {
+			t.Errorf("item %d: Cover = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestPostCover(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+
+	body := `<p><img src="` + srv.URL + `/first.jpg"></p>`
+	tests := []struct {
+		name, cover, content string
+		firstImage           bool
+		key, want            string
+	}{
+		{"feed cover", srv.URL + "/featured-1024x768.jpg", body, false, "cover", "cover: /media/2024-03-feed-cover/cover_featured.jpg\n"},
+		{"cover in body", srv.URL + "/first.jpg", body, false, "cover", "cover: /media/2024-03-cover-in-body/001_first.jpg\n"},
+		{"no cover", "", body, false, "cover", ""},
+		{"first image", "", body, true, "featured_image", "featured_image: /media/2024-03-first-image/001_first.jpg\n"},
+		{"no images", "", "<p>Text</p>", true, "cover", ""},
+	}
+	for _, tt := range tests {
+		setFlag(t, "cover-from-first-image", fmt.Sprint(tt.firstImage))
+		setFlag(t, "cover-key", tt.key)
+		slug := strings.ReplaceAll(tt.name, " ", "-")
+		item := Item{Title: tt.name, Link: "https://example.com/2024/03/05/" + slug + "/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000",
+			ContentEncoded: tt.content, Cover: tt.cover}
+		dl := conv.newDownloader(1, 1)
+		rec, err := conv.processItem(item, time.UTC, dl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dl.Wait()
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(data)
+		if tt.want == "" && strings.Contains(got, tt.key+":") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: got\n%s", tt.name, got)
+		}
+	}
+	if !fileExists(filepath.Join(static, "media", "2024-03-feed-cover", "cover_featured.jpg")) {
+		t.Error("feed cover not downloaded")
+	}
+	if fileExists(filepath.Join(static, "media", "2024-03-cover-in-body", "cover_first.jpg")) {
+		t.Error("cover used in the body downloaded twice")
+	}
+}
@@ -0,0 +1,137 @@
+package wordpress2hugo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// -dedupe-media: the same image is often served under several URLs (query
+// strings, size variants), so it is stored once per URL. The downloader
+// indexes every finished file by its SHA-256; after Wait, Dedupe keeps the
+// first path of each group and repointMedia updates the pages that used the
+// removed copies. Pages kept by -overwrite=false are never rewritten, so the
+// files they link to stay.
+
+// indexHash records dest under its content hash. Called with d.mu held.
+func (d *downloader) indexHash(dest, sum string) {
+	if d.byHash == nil {
+		d.byHash = make(map[string][]string)
+	}
+	d.byHash[sum] = append(d.byHash[sum], dest)
+}
+
+// Dedupe deletes all but one file per content hash and returns the removed
+// paths mapped to the kept one. Files in pinned (see preservedMedia) are
+// neither deleted nor replaced; a group keeps its first pinned file, if any.
+// Call it after Wait.
+func (d *downloader) Dedupe(pinned map[string]bool) map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	moved := map[string]string{}
+	for _, dests := range d.byHash {
+		var present []string
+		for _, p := range dests {
+			if fileExists(p) { // converted GIFs are gone
+				present = append(present, p)
+			}
+		}
+		sort.Strings(present)
+		if len(present) == 0 {
+			continue
+		}
+		keep := present[0]
+		for _, p := range present {
+			if pinned[p] {
+				keep = p
+				break
+			}
+		}
+		for i, dup := range present {
+			if dup == keep || pinned[dup] || (i > 0 && dup == present[i-1]) {
+				continue
+			}
+			if err := os.Remove(dup); err != nil && !errors.Is(err, os.ErrNotExist) {
+				d.c.warnf("dedupe %s: %v", dup, err)
+				continue
+			}
+			moved[dup] = keep
+			d.c.debugf("dedupe: %s is a copy of %s", dup, keep)
+		}
+	}
+	d.results.Range(func(k, v any) bool {
+		r := v.(dlResult)
+		if keep, ok := moved[r.dest]; ok {
+			r.dest = keep
+			d.results.Store(k, r)
+		}
+		return true
+	})
+	return moved
+}
+
+// preservedMedia are the media of the pages kept by -overwrite=false, which
+// Dedupe must leave in place.
+func preservedMedia(records []*postRecord) map[string]bool {
+	pinned := map[string]bool{}
+	for _, rec := range records {
+		if !rec.preserved {
+			continue
+		}
+		for _, a := range rec.Assets {
+			pinned[a.Dest] = true
+		}
+	}
+	return pinned
+}
+
+// repointMedia rewrites the pages whose media were removed by Dedupe to use
+// the kept copies, and updates their records. Pages kept by -overwrite=false
+// are left alone.
+func (c *Converter) repointMedia(records []*postRecord, moved map[string]string) error {
+	if len(moved) == 0 {
+		return nil
+	}
+	for _, rec := range records {
+		if rec.preserved {
+			continue
+		}
+		var pairs []string
+		for i := range rec.Assets {
+			a := &rec.Assets[i]
+			keep, ok := moved[a.Dest]
+			if !ok {
+				continue
+			}
+			from, ok1 := c.staticURL(a.Dest)
+			to, ok2 := c.staticURL(keep)
+			if ok1 && ok2 {
+				pairs = append(pairs, from, to)
+			}
+			a.Dest = keep
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			return err
+		}
+		out := strings.NewReplacer(pairs...).Replace(string(data))
+		if err := os.WriteFile(rec.File, []byte(out), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// staticURL is the site path of a file below -static.
+func (c *Converter) staticURL(p string) (string, bool) {
+	rel, err := filepath.Rel(c.opts.Static, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return "/" + filepath.ToSlash(rel), true
+}
@@ -0,0 +1,115 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupeMedia(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		if r.URL.Path == "/other.jpg" {
+			fmt.Fprint(w, "other")
+			return
+		}
+		fmt.Fprint(w, "same bytes")
+	}))
+	defer srv.Close()
+	out, static := t.TempDir(), t.TempDir()
+	setFlag(t, "out", out)
+	setFlag(t, "static", static)
+	setFlag(t, "dedupe-media", "true")
+	setFlag(t, "v", "false")
+
+	items := []Item{
+		{Title: "One", Link: "https://example.com/2024/03/05/one/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/photo.jpg"><img src="` + srv.URL + `/other.jpg"></p>`},
+		{Title: "Two", Link: "https://example.com/2024/03/06/two/", PubDate: "Wed, 06 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/photo-copy.jpg?v=2"></p>`},
+	}
+	dl := conv.newDownloader(2, 2)
+	var recs []*postRecord
+	for _, item := range items {
+		rec, err := conv.processItem(item, time.UTC, dl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	dl.Wait()
+	moved := dl.Dedupe(nil)
+	if err := conv.repointMedia(recs, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	kept := filepath.Join(static, "media", "2024-03-one", "001_photo.jpg")
+	dup := filepath.Join(static, "media", "2024-03-two", "001_photo-copy.jpg")
+	if len(moved) != 1 || moved[dup] != kept {
+		t.Errorf("moved = %v", moved)
+	}
+	if fileExists(dup) || !fileExists(kept) || !fileExists(filepath.Join(static, "media", "2024-03-one", "002_other.jpg")) {
+		t.Error("wrong files left on disk")
+	}
+	data, err := os.ReadFile(recs[1].File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "](/media/2024-03-one/001_photo.jpg)") || strings.Contains(string(data), "photo-copy.jpg)") {
+		t.Errorf("second post not pointed at the kept copy:\n%s", data)
+	}
+	if recs[1].Assets[0].Dest != kept {
+		t.Errorf("record dest = %s", recs[1].Assets[0].Dest)
+	}
+}
+
+func TestDedupeMediaPreserved(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "same bytes")
+	}))
+	defer srv.Close()
+	out, static := t.TempDir(), t.TempDir()
+	setFlag(t, "out", out)
+	setFlag(t, "static", static)
+	setFlag(t, "dedupe-media", "true")
+	setFlag(t, "overwrite", "false")
+	setFlag(t, "v", "false")
+
+	edited := filepath.Join(out, "2024-03-two.md")
+	const hand = "---\ntitle: Two\n---\n![](/media/2024-03-two/001_photo-copy.jpg) edited by hand\n"
+	if err := os.WriteFile(edited, []byte(hand), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	items := []Item{
+		{Title: "One", Link: "https://example.com/2024/03/05/one/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/photo.jpg"></p>`},
+		{Title: "Two", Link: "https://example.com/2024/03/06/two/", PubDate: "Wed, 06 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/photo-copy.jpg"></p>`},
+	}
+	dl := conv.newDownloader(2, 2)
+	var recs []*postRecord
+	for _, item := range items {
+		rec, err := conv.processItem(item, time.UTC, dl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	dl.Wait()
+	if err := conv.repointMedia(recs, dl.Dedupe(preservedMedia(recs))); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(edited); string(data) != hand {
+		t.Errorf("preserved page rewritten:\n%s", data)
+	}
+	if !fileExists(filepath.Join(static, "media", "2024-03-two", "001_photo-copy.jpg")) {
+		t.Error("media of the preserved page deleted")
+	}
+}
@@ -0,0 +1,30 @@
+package wordpress2hugo
+
+import "sync/atomic"
+
+// dryRunCounts tallies what a -dry-run would have written.
+type dryRunCounts struct {
+	posts, bytes, media atomic.Int64
+}
+
+func (c *dryRunCounts) addPost(n int) {
+	if c != nil {
+		c.posts.Add(1)
+		c.bytes.Add(int64(n))
+	}
+}
+
+func (c *dryRunCounts) addMedia() {
+	if c != nil {
+		c.media.Add(1)
+	}
+}
+
+// log logs the totals at info level.
+func (c *dryRunCounts) log(lg *logger) {
+	if c == nil {
+		return
+	}
+	lg.infof("dry-run: would write %d posts (%d bytes) and download %d media files",
+		c.posts.Load(), c.bytes.Load(), c.media.Load())
+}
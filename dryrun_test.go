@@ -0,0 +1,53 @@
+package wordpress2hugo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link>` +
+		`<content:encoded><![CDATA[<p><img src="` + srv.URL + `/a.jpg"><img src="` + srv.URL + `/b.png"></p>]]></content:encoded></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link><description>b</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	site := filepath.Join(dir, "site")
+	out, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(site, "content", "posts"), "-static", filepath.Join(site, "static"),
+		"-limit", "0", "-dry-run", "-clean", "-force", "-report", filepath.Join(site, "report.json"))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"dry-run: would clean",
+		"dry-run: would write " + filepath.Join(site, "content", "posts", "2024-02-first.md"),
+		"dry-run: would download " + srv.URL + "/a.jpg -> " + filepath.Join(site, "static", "media", "2024-02-first", "001_a.jpg"),
+		"dry-run: would write 2 posts (",
+		"and download 2 media files",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if _, err := os.Stat(site); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s (%v)", site, err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("dry run made %d requests", hits.Load())
+	}
+}
@@ -0,0 +1,125 @@
+package wordpress2hugo
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	youtubeIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	vimeoIDRe   = regexp.MustCompile(`^[0-9]+$`)
+	tweetUserRe = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+)
+
+// youtubeID extracts the video ID from the usual YouTube URL forms:
+// youtu.be/ID, youtube.com/watch?v=ID, /embed/ID, /shorts/ID, /live/ID and /v/ID
+// (also on m., www. and youtube-nocookie.com). Empty if rawURL is none of these.
+func youtubeID(rawURL string) string {
+	u, err := parseEmbedURL(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), "m.")
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	id := ""
+	switch host {
+	case "youtu.be":
+		id = segs[0]
+	case "youtube.com", "youtube-nocookie.com", "music.youtube.com":
+		switch {
+		case segs[0] == "watch":
+			id = u.Query().Get("v")
+		case len(segs) >= 2 && (segs[0] == "embed" || segs[0] == "shorts" || segs[0] == "live" || segs[0] == "v"):
+			id = segs[1]
+		}
+	}
+	if !youtubeIDRe.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// vimeoID extracts the numeric video ID from vimeo.com/ID,
+// player.vimeo.com/video/ID and channel/group URLs ending in the ID.
+func vimeoID(rawURL string) string {
+	u, err := parseEmbedURL(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "vimeo.com" && host != "player.vimeo.com" {
+		return ""
+	}
+	for _, seg := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		if vimeoIDRe.MatchString(seg) {
+			return seg
+		}
+	}
+	return ""
+}
+
+// tweetStatus extracts the user and status ID from a tweet permalink,
+// twitter.com/USER/status/ID or the same on x.com (also mobile. and www.).
+func tweetStatus(rawURL string) (user, id string) {
+	u, err := parseEmbedURL(rawURL)
+	if err != nil {
+		return "", ""
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), "mobile.")
+	if host != "twitter.com" && host != "x.com" {
+		return "", ""
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segs) < 3 || (segs[1] != "status" && segs[1] != "statuses") ||
+		!tweetUserRe.MatchString(segs[0]) || !vimeoIDRe.MatchString(segs[2]) {
+		return "", ""
+	}
+	return segs[0], segs[2]
+}
+
+// tweetShortcode is the {{< tweet >}} shortcode for an embedded tweet
+// (<blockquote class="twitter-tweet">), taken from its permalink: the last
+// status link, as the ones before it are links inside the tweet's text.
+func tweetShortcode(quote *goquery.Selection) (string, bool) {
+	sc := ""
+	quote.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if user, id := tweetStatus(a.AttrOr("href", "")); id != "" {
+			sc = tweetCode(user, id)
+		}
+	})
+	return sc, sc != ""
+}
+
+func tweetCode(user, id string) string {
+	return `{{< tweet user="` + user + `" id="` + id + `" >}}`
+}
+
+func parseEmbedURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if strings.HasPrefix(rawURL, "//") {
+		rawURL = "https:" + rawURL
+	}
+	return url.Parse(rawURL)
+}
+
+// embedShortcode returns the {{< youtube >}}, {{< vimeo >}} or {{< tweet >}}
+// shortcode for a video or tweet URL, ok=false for anything else.
+func embedShortcode(rawURL string) (string, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || strings.ContainsAny(rawURL, " \t\n") {
+		return "", false
+	}
+	if id := youtubeID(rawURL); id != "" {
+		return "{{< youtube " + id + " >}}", true
+	}
+	if id := vimeoID(rawURL); id != "" {
+		return "{{< vimeo " + id + " >}}", true
+	}
+	if user, id := tweetStatus(rawURL); id != "" {
+		return tweetCode(user, id), true
+	}
+	return "", false
+}
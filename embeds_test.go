@@ -0,0 +1,83 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestEmbedIDs(t *testing.T) {
+	tests := []struct {
+		url, youtube, vimeo string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", ""},
+		{"https://youtube.com/watch?feature=share&v=dQw4w9WgXcQ&t=42", "dQw4w9WgXcQ", ""},
+		{"https://m.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", ""},
+		{"https://youtu.be/dQw4w9WgXcQ?t=10", "dQw4w9WgXcQ", ""},
+		{"https://www.youtube.com/embed/dQw4w9WgXcQ?feature=oembed", "dQw4w9WgXcQ", ""},
+		{"//www.youtube-nocookie.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ", ""},
+		{"https://www.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ", ""},
+		{"https://www.youtube.com/channel/UCabc", "", ""},
+		{"https://www.youtube.com/watch?v=short", "", ""},
+		{"https://vimeo.com/76979871", "", "76979871"},
+		{"https://player.vimeo.com/video/76979871?h=abc&dnt=1", "", "76979871"},
+		{"https://vimeo.com/channels/staffpicks/76979871", "", "76979871"},
+		{"https://example.com/watch?v=dQw4w9WgXcQ", "", ""},
+	}
+	for _, tt := range tests {
+		if got := youtubeID(tt.url); got != tt.youtube {
+			t.Errorf("youtubeID(%q) = %q, want %q", tt.url, got, tt.youtube)
+		}
+		if got := vimeoID(tt.url); got != tt.vimeo {
+			t.Errorf("vimeoID(%q) = %q, want %q", tt.url, got, tt.vimeo)
+		}
+	}
+}
+
+func TestTweetStatus(t *testing.T) {
+	tests := []struct{ url, user, id string }{
+		{"https://twitter.com/jane/status/1234567890?ref_src=twsrc%5Etfw", "jane", "1234567890"},
+		{"https://mobile.twitter.com/jane_doe/status/1234567890", "jane_doe", "1234567890"},
+		{"https://x.com/jane/status/1234567890/photo/1", "jane", "1234567890"},
+		{"https://twitter.com/jane/statuses/1234567890", "jane", "1234567890"},
+		{"https://twitter.com/jane", "", ""},
+		{"https://twitter.com/hashtag/go", "", ""},
+		{"https://example.com/jane/status/1234567890", "", ""},
+	}
+	for _, tt := range tests {
+		if user, id := tweetStatus(tt.url); user != tt.user || id != tt.id {
+			t.Errorf("tweetStatus(%q) = %q, %q, want %q, %q", tt.url, user, id, tt.user, tt.id)
+		}
+	}
+}
+
+func TestEmbedsToShortcodes(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"iframe", `<p>Watch:</p><iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ?feature=oembed" width="560"></iframe>`,
+			"Watch:\n\n{{< youtube dQw4w9WgXcQ >}}"},
+		{"nested iframe", `<div class="video"><iframe src="https://player.vimeo.com/video/76979871"></iframe></div>`,
+			"{{< vimeo 76979871 >}}"},
+		{"bare url paragraph", `<p><a href="https://youtu.be/dQw4w9WgXcQ">https://youtu.be/dQw4w9WgXcQ</a></p>`,
+			"{{< youtube dQw4w9WgXcQ >}}"},
+		{"embed block", `<figure class="wp-block-embed is-type-video"><div class="wp-block-embed__wrapper">
+https://vimeo.com/76979871
+</div></figure>`, "{{< vimeo 76979871 >}}"},
+		{"bare url text", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "{{< youtube dQw4w9WgXcQ >}}"},
+		{"url in a sentence", `<p>See https://youtu.be/dQw4w9WgXcQ for more</p>`, "See https://youtu.be/dQw4w9WgXcQ for more"},
+		{"other iframe", `<p>Map</p><iframe src="https://maps.example.com/embed"></iframe>`, "Map"},
+		{"tweet", `<blockquote class="twitter-tweet" data-width="550"><p lang="en" dir="ltr">Hi <a href="https://twitter.com/bob/status/111">quoted</a> <a href="https://t.co/abc">pic.twitter.com/abc</a></p>&mdash; Jane (@jane) <a href="https://twitter.com/jane/status/1234567890?ref_src=twsrc%5Etfw">March 5, 2024</a></blockquote><script async src="https://platform.twitter.com/widgets.js" charset="utf-8"></script>`,
+			`{{< tweet user="jane" id="1234567890" >}}`},
+		{"tweet embed block", `<figure class="wp-block-embed is-provider-twitter"><div class="wp-block-embed__wrapper"><blockquote class="twitter-tweet"><p>Hi</p>&mdash; Jane <a href="https://x.com/jane/status/42">March 5</a></blockquote></div></figure>`,
+			`{{< tweet user="jane" id="42" >}}`},
+		{"bare tweet url", `<p>https://x.com/jane/status/42</p>`, `{{< tweet user="jane" id="42" >}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := conv.toMarkdownPreserveOrder(tt.in, "s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package wordpress2hugo
+
+// emojiNames is the keyword table for -emoji-slug name. Emoji missing here
+// fall back to their code (u1F600).
+var emojiNames = map[rune]string{
+	0x1F600: "grin", 0x1F601: "grin", 0x1F602: "joy", 0x1F603: "smile", 0x1F604: "smile",
+	0x1F605: "sweat-smile", 0x1F609: "wink", 0x1F60A: "blush", 0x1F60D: "heart-eyes", 0x1F60E: "cool",
+	0x1F618: "kiss", 0x1F622: "cry", 0x1F62D: "sob", 0x1F631: "scream", 0x1F642: "smile",
+	0x1F643: "upside-down", 0x1F914: "thinking", 0x1F923: "rofl", 0x1F970: "love",
+	0x1F44D: "thumbs-up", 0x1F44E: "thumbs-down", 0x1F44F: "clap", 0x1F64F: "pray", 0x1F4AA: "strong",
+	0x1F389: "party", 0x1F38A: "confetti", 0x1F381: "gift", 0x1F382: "birthday", 0x1F384: "christmas",
+	0x1F525: "fire", 0x1F4A1: "idea", 0x1F4AF: "100", 0x1F680: "rocket", 0x1F308: "rainbow",
+	0x1F31E: "sun", 0x2600: "sun", 0x2601: "cloud", 0x2614: "rain", 0x2744: "snow", 0x26A1: "zap",
+	0x2764: "heart", 0x1F494: "broken-heart", 0x1F31F: "star", 0x2728: "sparkles",
+	0x2705: "check", 0x274C: "cross", 0x26A0: "warning",
+	0x1F436: "dog", 0x1F431: "cat", 0x1F334: "palm", 0x1F332: "tree", 0x1F33B: "sunflower", 0x1F337: "tulip",
+	0x1F355: "pizza", 0x1F354: "burger", 0x1F370: "cake", 0x1F37A: "beer", 0x1F377: "wine", 0x2615: "coffee",
+	0x1F3B5: "music", 0x1F4F7: "camera", 0x1F4DA: "books", 0x1F4BB: "laptop", 0x1F3E0: "home",
+	0x2708: "plane", 0x1F697: "car", 0x1F6B2: "bike", 0x1F3D6: "beach", 0x26F0: "mountain", 0x1F30D: "earth",
+}
@@ -0,0 +1,72 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Enclosures are the files attached to a feed item: podcast episodes, videos,
+// PDFs, … Those the post body doesn't reference already are downloaded into
+// the post's media folder like its images. Audio and video become players at
+// the end of the body; other files are linked there. Image enclosures serve as
+// the cover instead (see loadRSS).
+
+func enclosures(encs []*gofeed.Enclosure) []Enclosure {
+	var out []Enclosure
+	for _, e := range encs {
+		if e != nil && strings.TrimSpace(e.URL) != "" {
+			out = append(out, Enclosure{URL: strings.TrimSpace(e.URL), Type: strings.ToLower(strings.TrimSpace(e.Type)), Length: e.Length})
+		}
+	}
+	return out
+}
+
+// enclosureKind is "audio", "video", "image" or "file".
+func (e Enclosure) kind() string {
+	kind, _, _ := strings.Cut(e.Type, "/")
+	switch kind {
+	case "audio", "video", "image":
+		return kind
+	}
+	return "file"
+}
+
+// enclosureHTML is a player for each audio and video enclosure the body
+// doesn't use already, to be appended to it; downloading and rewriting then
+// work as for <audio>/<video> in the body.
+func enclosureHTML(encs []Enclosure, body string) string {
+	var b strings.Builder
+	for _, e := range encs {
+		kind := e.kind()
+		if (kind != "audio" && kind != "video") || strings.Contains(body, e.URL) {
+			continue
+		}
+		fmt.Fprintf(&b, `<figure class="wp-block-%[1]s"><%[1]s controls src="%[2]s"></%[1]s></figure>`, kind, html.EscapeString(e.URL))
+	}
+	return b.String()
+}
+
+// attachEnclosures downloads the other enclosures (PDFs, archives, …) the
+// body doesn't link to and appends a link to each of them to body.
+func (c *Converter) attachEnclosures(body string, encs []Enclosure, contentHTML, mediaName string, dl *downloader, rec *postRecord) string {
+	for _, e := range encs {
+		if e.kind() != "file" || strings.Contains(contentHTML, e.URL) {
+			continue
+		}
+		dest := c.scheduleMedia(dl, e.URL, filepath.Join(c.mediaDir(mediaName), filenameFromURL(e.URL)), false)
+		rec.addAsset(e.URL, dest)
+		name := filepath.Base(dest)
+		rel := path.Join(c.mediaURL(mediaName), name)
+		if c.opts.ContentFormat == "html" {
+			body += fmt.Sprintf("\n<p><a href=\"%s\">%s</a></p>", html.EscapeString(rel), html.EscapeString(name))
+		} else {
+			body += fmt.Sprintf("\n\n[%s](%s)", name, rel)
+		}
+	}
+	return body
+}
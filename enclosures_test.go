@@ -0,0 +1,124 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudio(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "mp3")
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+
+	feedPath := filepath.Join(t.TempDir(), "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>Episode 1</title><link>https://example.com/2024/03/05/episode-1/</link><pubDate>Tue, 05 Mar 2024 10:00:00 +0000</pubDate>` +
+		`<content:encoded><![CDATA[<p>Intro</p><figure class="wp-block-audio"><audio controls><source src="` + srv.URL + `/intro.mp3" type="audio/mpeg"></audio></figure>` +
+		`<p><audio src="` + srv.URL + `/clip.ogg"></audio></p>]]></content:encoded>` +
+		`<enclosure url="` + srv.URL + `/episode-1.mp3" length="3" type="audio/mpeg"/>` +
+		`<enclosure url="` + srv.URL + `/clip.ogg" length="3" type="audio/ogg"/>` +
+		`</item></channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, err := conv.loadRSS(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rss.Channel.Items[0].Enclosures); n != 2 {
+		t.Fatalf("%d enclosures, want 2", n)
+	}
+	dl := conv.newDownloader(2, 2)
+	rec, err := conv.processItem(rss.Channel.Items[0], time.UTC, dl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.Wait()
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	want := "Intro\n\n[Audio: intro.mp3](/media/2024-03-episode-1/intro.mp3)\n\n" +
+		"[Audio: clip.ogg](/media/2024-03-episode-1/clip.ogg)\n\n" +
+		"[Audio: episode-1.mp3](/media/2024-03-episode-1/episode-1.mp3)\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got\n%s\nwant it to end with\n%s", got, want)
+	}
+	for _, name := range []string{"intro.mp3", "clip.ogg", "episode-1.mp3"} {
+		if !fileExists(filepath.Join(static, "media", "2024-03-episode-1", name)) {
+			t.Errorf("%s not downloaded", name)
+		}
+	}
+}
+
+func TestEnclosures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data")
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+
+	feedPath := filepath.Join(t.TempDir(), "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>Handout</title><link>https://example.com/2024/03/05/handout/</link><pubDate>Tue, 05 Mar 2024 10:00:00 +0000</pubDate>` +
+		`<content:encoded><![CDATA[<p>See the <a href="` + srv.URL + `/linked.pdf">slides</a>.</p>]]></content:encoded>` +
+		`<enclosure url="` + srv.URL + `/handout.pdf" length="4" type="application/pdf"/>` +
+		`<enclosure url="` + srv.URL + `/linked.pdf" length="4" type="application/pdf"/>` +
+		`<enclosure url="` + srv.URL + `/talk.mp4" length="4" type="video/mp4"/>` +
+		`<enclosure url="` + srv.URL + `/poster.jpg" length="4" type="image/jpeg"/>` +
+		`</item></channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, err := conv.loadRSS(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := rss.Channel.Items[0]
+	if item.Cover != srv.URL+"/poster.jpg" {
+		t.Errorf("Cover = %q, want the image enclosure", item.Cover)
+	}
+	dl := conv.newDownloader(2, 2)
+	rec, err := conv.processItem(item, time.UTC, dl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.Wait()
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"[Video: talk.mp4](/media/2024-03-handout/talk.mp4)",
+		"\n\n[handout.pdf](/media/2024-03-handout/handout.pdf)\n",
+		"cover: /media/2024-03-handout/cover_poster.jpg\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got\n%s\nwant it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "[linked.pdf]") {
+		t.Errorf("enclosure linked from the body added again:\n%s", got)
+	}
+	for _, name := range []string{"handout.pdf", "talk.mp4", "cover_poster.jpg"} {
+		if !fileExists(filepath.Join(static, "media", "2024-03-handout", name)) {
+			t.Errorf("%s not downloaded", name)
+		}
+	}
+}
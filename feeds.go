@@ -0,0 +1,86 @@
+package wordpress2hugo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// splitFeeds splits the comma-separated -feed value.
+func splitFeeds(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// loadFeeds loads every source with load and merges their items in order.
+// An item seen in an earlier feed (same GUID, or link without one) is
+// skipped, so cross-posted articles are imported once and keep the
+// -source-name of the first feed that has them. It returns
+// ErrNotModified only when no feed changed since the last run (-feed-state);
+// otherwise unchanged feeds are fetched again in full.
+func (c *Converter) loadFeeds(srcs []string, load func(string) (*RSS, error)) (*RSS, error) {
+	if len(srcs) == 0 {
+		return nil, fmt.Errorf("no feed given")
+	}
+	feeds := make([]*RSS, len(srcs))
+	var unchanged []int
+	for i, src := range srcs {
+		rss, err := load(src)
+		if errors.Is(err, ErrNotModified) {
+			unchanged = append(unchanged, i)
+			continue
+		}
+		if err != nil {
+			return nil, feedError(srcs, src, err)
+		}
+		feeds[i] = rss
+	}
+	if len(unchanged) == len(srcs) {
+		return nil, ErrNotModified
+	}
+	for _, i := range unchanged {
+		c.feedCache.forget(srcs[i])
+		rss, err := load(srcs[i])
+		if err != nil {
+			return nil, feedError(srcs, srcs[i], err)
+		}
+		feeds[i] = rss
+	}
+
+	names := splitFeeds(c.opts.SourceNames)
+	merged := &RSS{}
+	seen := map[string]bool{}
+	for i, rss := range feeds {
+		src := srcs[i]
+		if i == 0 {
+			merged.Channel.Title = rss.Channel.Title
+		}
+		merged.Channel.Categories = append(merged.Channel.Categories, rss.Channel.Categories...)
+		for _, item := range rss.Channel.Items {
+			id := itemID(item)
+			if seen[id] {
+				c.debugf("skipping duplicate %s from %s", id, src)
+				continue
+			}
+			seen[id] = true
+			if i < len(names) {
+				item.SourceName = names[i]
+			}
+			merged.Channel.Items = append(merged.Channel.Items, item)
+		}
+	}
+	return merged, nil
+}
+
+// feedError names the failing feed when there are several.
+func feedError(srcs []string, src string, err error) error {
+	if len(srcs) > 1 {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFeedsMergesAndDedupes(t *testing.T) {
+	setFlag(t, "v", "false")
+	dir := t.TempDir()
+	write := func(name string, items ...string) string {
+		p := filepath.Join(dir, name)
+		feed := `<?xml version="1.0"?><rss version="2.0"><channel><title>` + name + `</title>` + strings.Join(items, "") + `</channel></rss>`
+		if err := os.WriteFile(p, []byte(feed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	item := func(guid, title string) string {
+		return fmt.Sprintf(`<item><title>%s</title><link>https://example.com/%s/</link><guid>%s</guid></item>`, title, guid, guid)
+	}
+	a := write("a.xml", item("g1", "One"), item("g2", "Two"))
+	b := write("b.xml", item("g2", "Two (cross-posted)"), item("g3", "Three"))
+
+	rss, err := conv.loadFeeds(splitFeeds(a+", "+b), conv.loadRSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, it := range rss.Channel.Items {
+		titles = append(titles, it.Title)
+	}
+	if got := strings.Join(titles, "|"); got != "One|Two|Three" {
+		t.Errorf("items = %s, want One|Two|Three", got)
+	}
+
+	single, err := conv.loadFeeds(splitFeeds(a), conv.loadRSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single.Channel.Items) != 2 || single.Channel.Title != "a.xml" {
+		t.Errorf("single feed: %d items, title %q", len(single.Channel.Items), single.Channel.Title)
+	}
+
+	if _, err := conv.loadFeeds(splitFeeds(a+","+filepath.Join(dir, "missing.xml")), conv.loadRSS); err == nil || !strings.Contains(err.Error(), "missing.xml") {
+		t.Errorf("missing feed: err = %v", err)
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	setFlag(t, "v", "false")
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "source-name", "Blog A, Blog B")
+	dir := t.TempDir()
+	write := func(name string, items ...string) string {
+		p := filepath.Join(dir, name)
+		feed := `<?xml version="1.0"?><rss version="2.0"><channel><title>` + name + `</title>` + strings.Join(items, "") + `</channel></rss>`
+		if err := os.WriteFile(p, []byte(feed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	item := func(guid string) string {
+		return fmt.Sprintf(`<item><title>%s</title><link>https://example.com/2024/03/05/%s/</link><guid>%s</guid></item>`, guid, guid, guid)
+	}
+	a := write("a.xml", item("one"), item("two"))
+	b := write("b.xml", item("two"), item("three"))
+
+	rss, err := conv.loadFeeds(splitFeeds(a+","+b), conv.loadRSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range rss.Channel.Items {
+		got = append(got, it.Title+"="+it.SourceName)
+	}
+	if s := strings.Join(got, "|"); s != "one=Blog A|two=Blog A|three=Blog B" {
+		t.Errorf("sources = %s", s)
+	}
+
+	rec, err := conv.processItem(rss.Channel.Items[2], time.UTC, conv.newDownloader(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "source:\n    - Blog B\n") {
+		t.Errorf("front matter has no source term:\n%s", data)
+	}
+}
@@ -0,0 +1,104 @@
+package wordpress2hugo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotModified is returned by LoadItems (and loadRSS) when the server
+// answers a conditional request (-feed-state) with 304 Not Modified.
+var ErrNotModified = errors.New("not modified")
+
+type feedValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+type feedState struct {
+	mu   sync.Mutex
+	path string
+	prev map[string]feedValidators // from the last run, sent as conditions
+	next map[string]feedValidators // from this run's responses, saved at the end
+}
+
+// loadFeedState reads the state file; a missing one is an empty state.
+func loadFeedState(path string) (*feedState, error) {
+	s := &feedState{path: path, prev: map[string]feedValidators{}, next: map[string]feedValidators{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.prev); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// addConditions makes req conditional on the validators src had last run.
+func (s *feedState) addConditions(req *http.Request, src string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	v := s.prev[src]
+	s.mu.Unlock()
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// remember keeps the validators of a fresh response for the next run.
+func (s *feedState) remember(src string, h http.Header) {
+	if s == nil {
+		return
+	}
+	v := feedValidators{ETag: h.Get("ETag"), LastModified: h.Get("Last-Modified")}
+	if v == (feedValidators{}) {
+		return
+	}
+	s.mu.Lock()
+	s.next[src] = v
+	s.mu.Unlock()
+}
+
+// forget drops the old validators of src, so it is fetched in full.
+func (s *feedState) forget(src string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.prev, src)
+	s.mu.Unlock()
+}
+
+// save writes the validators of this run. It is a no-op without -feed-state.
+func (s *feedState) save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(s.next, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
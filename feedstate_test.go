@@ -0,0 +1,117 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// feedServer serves a one-item feed whose ETag changes with *version.
+func feedServer(t *testing.T, version *atomic.Int32, full *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><item><title>Post</title>`+
+			`<link>%s/2024/01/02/post/</link><pubDate>Tue, 02 Jan 2024 10:00:00 +0000</pubDate></item></channel></rss>`, "http://"+r.Host)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFeedStateConditionalRequests(t *testing.T) {
+	setFlag(t, "v", "false")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	var verA, verB, fullA, fullB atomic.Int32
+	a, b := feedServer(t, &verA, &fullA), feedServer(t, &verB, &fullB)
+	t.Cleanup(func() { conv.feedCache = nil })
+
+	run := func() error {
+		st, err := loadFeedState(statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conv.feedCache = st
+		_, err = conv.loadFeeds([]string{a.URL + "/feed/", b.URL + "/feed/"}, conv.loadRSS)
+		if err == nil {
+			if err := conv.feedCache.save(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return err
+	}
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err != ErrNotModified {
+		t.Fatalf("unchanged feeds: err = %v, want ErrNotModified", err)
+	}
+	// one feed changed: the other is fetched again in full for the merge
+	verB.Add(1)
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if fullA.Load() != 2 || fullB.Load() != 2 {
+		t.Errorf("full fetches a=%d b=%d, want 2 each", fullA.Load(), fullB.Load())
+	}
+	if err := run(); err != ErrNotModified {
+		t.Errorf("after the change: err = %v, want ErrNotModified", err)
+	}
+}
+
+func TestFeedStateSkipsRun(t *testing.T) {
+	var version, full atomic.Int32
+	srv := feedServer(t, &version, &full)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "content")
+	args := []string{"-feed", srv.URL + "/feed/", "-out", out, "-static", filepath.Join(dir, "static"), "-yes",
+		"-feed-state", filepath.Join(dir, "feed-state.json")}
+	if log, err := runMain(t, args...); err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	post := filepath.Join(out, "2024-01-post.md")
+	if err := os.WriteFile(post, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	log, err := runMain(t, args...)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	if !strings.Contains(log, "feed not modified") {
+		t.Errorf("no early exit:\n%s", log)
+	}
+	if got, _ := os.ReadFile(post); string(got) != "edited" {
+		t.Errorf("output was cleaned or rewritten: %q", got)
+	}
+}
+
+func TestFeedStateNotSavedOnFailedItems(t *testing.T) {
+	var version, full atomic.Int32
+	srv := feedServer(t, &version, &full)
+	dir := t.TempDir()
+	state := filepath.Join(dir, "feed-state.json")
+	args := []string{"-feed", srv.URL + "/feed/", "-out", filepath.Join(dir, "content"), "-static", filepath.Join(dir, "static"),
+		"-feed-state", state, "-frontmatter-template", "{{.Nope}}"}
+	log, err := runMain(t, args...)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	if !strings.Contains(log, "processing item") {
+		t.Fatalf("item did not fail:\n%s", log)
+	}
+	if fileExists(state) {
+		t.Error("feed state saved despite the failed item")
+	}
+	if log, err := runMain(t, args...); err != nil || strings.Contains(log, "feed not modified") || full.Load() != 2 {
+		t.Errorf("failed item not retried (%d full fetches, err %v):\n%s", full.Load(), err, log)
+	}
+}
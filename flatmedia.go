@@ -0,0 +1,66 @@
+package wordpress2hugo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// -flatten-images: the media of all posts go into one folder, static/media,
+// under their original file names. A name already taken by another URL gets
+// a short hash of the URL as prefix; a URL used by several posts keeps one
+// file, so it is downloaded once.
+type flatNameSet struct {
+	mu     sync.Mutex
+	byName map[string]string // lowercased file name -> URL
+	byURL  map[string]string // URL -> file name
+}
+
+func newFlatNameSet() *flatNameSet {
+	return &flatNameSet{byName: map[string]string{}, byURL: map[string]string{}}
+}
+
+// dest returns the path rawURL is saved to: dest itself, or the name rawURL
+// got before, or dest's name with a hash prefix when another URL has it.
+func (s *flatNameSet) dest(rawURL, dest string) string {
+	if s == nil {
+		return dest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Dir(dest)
+	if name, ok := s.byURL[rawURL]; ok {
+		return filepath.Join(dir, name)
+	}
+	name := filepath.Base(dest)
+	if owner, taken := s.byName[strings.ToLower(name)]; taken && owner != rawURL {
+		name = urlHash(rawURL) + "_" + name
+	}
+	s.add(rawURL, name)
+	return filepath.Join(dir, name)
+}
+
+// reserve registers the media of a post kept from an earlier run (-resume).
+func (s *flatNameSet) reserve(rec *postRecord) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range rec.Assets {
+		s.add(a.URL, filepath.Base(a.Dest))
+	}
+}
+
+func (s *flatNameSet) add(rawURL, name string) {
+	s.byURL[rawURL] = name
+	s.byName[strings.ToLower(name)] = rawURL
+}
+
+// urlHash is a short, stable hash of rawURL for file name prefixes.
+func urlHash(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,70 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlattenImages(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+	old := conv.flatMedia
+	conv.flatMedia = newFlatNameSet()
+	t.Cleanup(func() { conv.flatMedia = old })
+
+	items := []Item{
+		{Title: "One", Link: "https://example.com/2024/03/05/one/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/2024/03/photo.jpg"></p><p><img src="` + srv.URL + `/shared.png"></p>`},
+		{Title: "Two", Link: "https://example.com/2024/03/06/two/", PubDate: "Wed, 06 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/shared.png"></p><p><img src="` + srv.URL + `/2024/04/photo.jpg"></p>`},
+	}
+	dl := conv.newDownloader(2, 2)
+	var pages []string
+	for _, item := range items {
+		rec, err := conv.processItem(item, time.UTC, dl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, string(data))
+	}
+	dl.Wait()
+
+	renamed := urlHash(srv.URL+"/2024/04/photo.jpg") + "_photo.jpg"
+	for i, want := range [][]string{
+		{"(/media/photo.jpg)", "(/media/shared.png)"},
+		{"(/media/shared.png)", "(/media/" + renamed + ")"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(pages[i], w) {
+				t.Errorf("post %d lacks %s:\n%s", i+1, w, pages[i])
+			}
+		}
+	}
+	for name, body := range map[string]string{"photo.jpg": "/2024/03/photo.jpg", "shared.png": "/shared.png", renamed: "/2024/04/photo.jpg"} {
+		if got, err := os.ReadFile(filepath.Join(static, "media", name)); err != nil || string(got) != body {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, body)
+		}
+	}
+	if hits.Load() != 3 {
+		t.Errorf("%d downloads, want 3", hits.Load())
+	}
+}
@@ -0,0 +1,406 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fmMap is an insertion-ordered map used to marshal front matter.
+// Keys may be dotted paths ("params.topics"), which are expanded into nested maps.
+type fmMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newFMMap() *fmMap {
+	return &fmMap{values: make(map[string]any)}
+}
+
+// Set stores v under key. A dotted key creates (or reuses) nested maps for each
+// leading segment, so "params.topics" ends up as params: {topics: v}.
+func (m *fmMap) Set(key string, v any) {
+	key = strings.Trim(key, ". ")
+	if key == "" {
+		return
+	}
+	head, rest, nested := strings.Cut(key, ".")
+	if nested {
+		child, ok := m.values[head].(*fmMap)
+		if !ok {
+			child = newFMMap()
+			m.put(head, child)
+		}
+		child.Set(rest, v)
+		return
+	}
+	m.put(key, v)
+}
+
+func (m *fmMap) put(key string, v any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// MarshalYAML emits the keys in insertion order (yaml.v3 would sort a plain map).
+func (m *fmMap) MarshalYAML() (any, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range m.keys {
+		kn := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+		vn := &yaml.Node{}
+		if err := vn.Encode(m.values[k]); err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, kn, vn)
+	}
+	return n, nil
+}
+
+// MarshalJSON emits the keys in insertion order.
+func (m *fmMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := jsonValue(k)
+		if err != nil {
+			return nil, err
+		}
+		v, err := jsonValue(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func jsonValue(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// writeTOML emits the plain keys of m in insertion order, then each nested map
+// as a [table]. Dates are bare RFC3339 values and lists are inline arrays.
+func (m *fmMap) writeTOML(buf *bytes.Buffer, prefix string) error {
+	var tables []string
+	for _, k := range m.keys {
+		if _, ok := m.values[k].(*fmMap); ok {
+			tables = append(tables, k)
+			continue
+		}
+		v, err := tomlValue(m.values[k])
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(k), v)
+	}
+	for _, k := range tables {
+		name := prefix + tomlKey(k)
+		fmt.Fprintf(buf, "\n[%s]\n", name)
+		if err := m.values[k].(*fmMap).writeTOML(buf, name+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tomlKey(k string) string {
+	for _, r := range k {
+		if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return tomlString(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+// tomlString quotes s as a basic string; JSON escapes are valid TOML escapes.
+func tomlString(s string) string {
+	b, _ := jsonValue(s)
+	return string(b)
+}
+
+func tomlValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return `""`, nil
+	case string:
+		return tomlString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case dateValue:
+		if v.tomlDate() {
+			return string(v), nil
+		}
+		return tomlString(string(v)), nil
+	case []string:
+		items := make([]string, len(v))
+		for i, s := range v {
+			items[i] = tomlString(s)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case []any:
+		items := make([]string, len(v))
+		for i, e := range v {
+			s, err := tomlValue(e)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			s, err := tomlValue(v[k])
+			if err != nil {
+				return "", err
+			}
+			items[i] = tomlKey(k) + " = " + s
+		}
+		return "{" + strings.Join(items, ", ") + "}", nil
+	case *fmMap:
+		items := make([]string, len(v.keys))
+		for i, k := range v.keys {
+			s, err := tomlValue(v.values[k])
+			if err != nil {
+				return "", err
+			}
+			items[i] = tomlKey(k) + " = " + s
+		}
+		return "{" + strings.Join(items, ", ") + "}", nil
+	}
+	return "", fmt.Errorf("unsupported TOML value %T", v)
+}
+
+// marshalFrontMatter renders m in the -frontmatter format including its
+// delimiters: --- for YAML, +++ for TOML, and a bare object for JSON.
+func (c *Converter) marshalFrontMatter(m *fmMap) ([]byte, error) {
+	var buf bytes.Buffer
+	switch c.opts.FrontMatterFormat {
+	case "toml":
+		buf.WriteString("+++\n")
+		if err := m.writeTOML(&buf, ""); err != nil {
+			return nil, err
+		}
+		buf.WriteString("+++\n")
+	case "json":
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+	default:
+		data, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+		buf.WriteString("---\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// toMap lays out the front matter fields in their output order, placing the
+// taxonomy lists under the keys o configures.
+func (fm FrontMatter) toMap(o *Options) *fmMap {
+	m := newFMMap()
+	m.Set("title", fm.Title)
+	if fm.Slug != "" {
+		m.Set("slug", fm.Slug)
+	}
+	m.Set("date", fmDate(fm.Date, o))
+	if fm.LastMod.After(fm.Date) {
+		m.Set("lastmod", fmDate(fm.LastMod, o))
+	}
+	m.Set("draft", fm.Draft)
+	if fm.Author != "" {
+		m.Set("author", fm.Author)
+	}
+	if fm.Summary != "" {
+		m.Set("description", fm.Summary)
+	}
+	if fm.WordCount > 0 {
+		m.Set("readingTime", fm.ReadingTime)
+		m.Set("wordCount", fm.WordCount)
+	}
+	// empty lists are left out rather than written as []
+	if len(fm.Tags) > 0 {
+		m.Set(o.TagsKey, fm.Tags)
+	}
+	if len(fm.Aliases) > 0 {
+		m.Set("aliases", fm.Aliases)
+	}
+	if fm.Canonical != "" {
+		m.Set("canonicalURL", fm.Canonical)
+	}
+	if len(fm.Categories) > 0 {
+		m.Set(o.CategoriesKey, fm.Categories)
+	}
+	if fm.Source != "" {
+		m.Set(o.SourceKey, []string{fm.Source})
+	}
+	if fm.Kind != "" {
+		m.Set(o.FormatKey, fm.Kind)
+	}
+	if fm.Thumbnail != "" {
+		m.Set("thumbnail", fm.Thumbnail)
+	}
+	if fm.Cover != "" {
+		m.Set(o.CoverKey, fm.Cover)
+	}
+	if fm.Extra != nil {
+		for _, k := range fm.Extra.keys {
+			m.Set(k, fm.Extra.values[k])
+		}
+	}
+	return m
+}
+
+// fmDate is a front matter date per -utc and -date-format: the time itself
+// for rfc3339, else its text in the dateonly or custom Go layout.
+func fmDate(t time.Time, o *Options) any {
+	if o.UTC {
+		t = t.UTC()
+	}
+	switch o.DateFormat {
+	case "rfc3339":
+		return t
+	case "dateonly":
+		return dateValue(t.Format(time.DateOnly))
+	}
+	return dateValue(t.Format(o.DateFormat))
+}
+
+// dateValue is a formatted date. YAML and TOML get it unquoted when it still
+// reads as a date there (2024-03-05), otherwise as a string.
+type dateValue string
+
+func (d dateValue) MarshalYAML() (any, error) {
+	var v any
+	if err := yaml.Unmarshal([]byte(d), &v); err == nil {
+		if _, ok := v.(time.Time); ok {
+			return &yaml.Node{Kind: yaml.ScalarNode, Value: string(d)}, nil
+		}
+	}
+	return string(d), nil
+}
+
+// tomlDate reports whether d is a TOML date, local or offset date-time.
+func (d dateValue) tomlDate() bool {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", time.DateOnly} {
+		if _, err := time.Parse(layout, string(d)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+var fmTemplateFuncs = template.FuncMap{
+	"add":        func(a, b int64) int64 { return a + b },
+	"sub":        func(a, b int64) int64 { return a - b },
+	"mul":        func(a, b int64) int64 { return a * b },
+	"div":        func(a, b int64) int64 { return a / b },
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"split":      strings.Split,
+	"hasPrefix":  strings.HasPrefix,
+	"trimPrefix": strings.TrimPrefix,
+	"replace":    strings.ReplaceAll,
+}
+
+// fmTemplateData is what a -frontmatter-template sees: the raw feed item plus
+// the values computed for the post.
+type fmTemplateData struct {
+	Item       Item
+	Slug       string
+	Title      string
+	Date       time.Time
+	Tags       []string
+	Categories []string
+}
+
+// parseFMTemplate parses the -frontmatter-template value, which is either the
+// template text itself or a path to a file containing it.
+func parseFMTemplate(src string) (*template.Template, error) {
+	if fileExists(src) {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+		src = string(data)
+	}
+	return template.New("frontmatter").Funcs(fmTemplateFuncs).Option("missingkey=error").Parse(src)
+}
+
+// execFMTemplate runs the template and decodes its output as a YAML mapping,
+// keeping the key order of the output. Dotted keys nest like the taxonomy keys.
+func execFMTemplate(t *template.Template, data fmTemplateData) (*fmMap, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		return nil, fmt.Errorf("template output is not YAML: %w", err)
+	}
+	m := newFMMap()
+	if len(doc.Content) == 0 {
+		return m, nil // empty output adds nothing
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("template output is not a YAML mapping")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		var v any
+		if err := root.Content[i+1].Decode(&v); err != nil {
+			return nil, err
+		}
+		m.Set(root.Content[i].Value, v)
+	}
+	return m, nil
+}
@@ -0,0 +1,217 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFrontMatterTaxonomyKeys(t *testing.T) {
+	fm := FrontMatter{
+		Title:      "Post",
+		Date:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Tags:       []string{"go"},
+		Categories: []string{"Dev"},
+	}
+	tests := []struct {
+		name, tagsKey, catsKey string
+		want                   string
+	}{
+		{"default keys", "tags", "categories", "tags:\n    - go\ncategories:\n    - Dev\n"},
+		{"nested", "params.tags", "params.categories", "params:\n    tags:\n        - go\n    categories:\n        - Dev\n"},
+		{"other top-level keys", "topics", "sections", "topics:\n    - go\nsections:\n    - Dev\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, "tags-key", tt.tagsKey)
+			setFlag(t, "categories-key", tt.catsKey)
+			out, err := yaml.Marshal(fm.toMap(conv.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(string(out), tt.want) {
+				t.Errorf("got\n%s\nwant it to end with\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrontMatterTemplate(t *testing.T) {
+	tmpl, err := parseFMTemplate(`weight: {{sub 2000000000 .Date.Unix}}
+{{with split .Title ": "}}{{if gt (len .) 1}}series: {{index . 0}}{{end}}{{end}}
+params.slug: {{.Slug}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Travel: Day 1", "weight: 290712800\nseries: Travel\nparams:\n    slug: s\n"},
+		{"Plain post", "weight: 290712800\nparams:\n    slug: s\n"},
+	}
+	for _, tt := range tests {
+		extra, err := execFMTemplate(tmpl, fmTemplateData{Title: tt.title, Slug: "s", Date: date})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := yaml.Marshal(FrontMatter{Title: tt.title, Date: date, Extra: extra}.toMap(conv.opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(out), tt.want) {
+			t.Errorf("%s: got\n%s\nwant it to end with\n%s", tt.title, out, tt.want)
+		}
+	}
+
+	if _, err := parseFMTemplate("weight: {{.Date"); err == nil {
+		t.Error("invalid template parsed without error")
+	}
+}
+
+func TestFrontMatterFormats(t *testing.T) {
+	extra := newFMMap()
+	extra.Set("params.series", "Travel")
+	fm := FrontMatter{
+		Title: `Say "hi"`,
+		Date:  time.Date(2024, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600)),
+		Tags:  []string{"go", "hugo"},
+		Extra: extra,
+	}
+	tests := []struct {
+		format, want string
+	}{
+		{"yaml", "---\ntitle: Say \"hi\"\ndate: 2024-03-01T10:00:00+01:00\ndraft: false\ntags:\n    - go\n    - hugo\nparams:\n    series: Travel\n---\n"},
+		{"toml", "+++\ntitle = \"Say \\\"hi\\\"\"\ndate = 2024-03-01T10:00:00+01:00\ndraft = false\ntags = [\"go\", \"hugo\"]\n\n[params]\nseries = \"Travel\"\n+++\n"},
+		{"json", "{\n  \"title\": \"Say \\\"hi\\\"\",\n  \"date\": \"2024-03-01T10:00:00+01:00\",\n  \"draft\": false,\n  \"tags\": [\n    \"go\",\n    \"hugo\"\n  ],\n  \"params\": {\n    \"series\": \"Travel\"\n  }\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setFlag(t, "frontmatter", tt.format)
+			out, err := conv.marshalFrontMatter(fm.toMap(conv.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrontMatterAuthor(t *testing.T) {
+	date := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	out, err := yaml.Marshal(FrontMatter{Title: "Post", Date: date, Author: "Klaus"}.toMap(conv.opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "draft: false\nauthor: Klaus\n") {
+		t.Errorf("author missing:\n%s", out)
+	}
+	out, err = yaml.Marshal(FrontMatter{Title: "Post", Date: date}.toMap(conv.opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "author") {
+		t.Errorf("empty author written:\n%s", out)
+	}
+}
+
+func TestFrontMatterCanonical(t *testing.T) {
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	tests := []struct {
+		canonical, link, want string
+	}{
+		{"true", "https://example.com/2024/03/05/post/", "canonicalURL: https://example.com/2024/03/05/post/\n"},
+		{"true", "", ""},
+		{"false", "https://example.com/2024/03/05/post/", ""},
+	}
+	for _, tt := range tests {
+		setFlag(t, "canonical", tt.canonical)
+		conv.postNames = nil
+		item := Item{Title: "Post", Link: tt.link, GUID: "p1", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000"}
+		rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(data)
+		if tt.want == "" && strings.Contains(got, "canonicalURL") || !strings.Contains(got, tt.want) {
+			t.Errorf("-canonical=%s link %q: got\n%s", tt.canonical, tt.link, got)
+		}
+	}
+}
+
+func TestFrontMatterLastMod(t *testing.T) {
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		item Item
+		want string
+	}{
+		{"feed updated", Item{PubDate: "Tue, 05 Mar 2024 10:00:00 +0000", Updated: "2024-04-01T08:30:00Z"}, "lastmod: 2024-04-01T10:30:00+02:00\n"},
+		{"updated equals date", Item{PubDate: "Tue, 05 Mar 2024 10:00:00 +0000", Updated: "2024-03-05T10:00:00Z"}, ""},
+		{"updated before date", Item{PubDate: "Tue, 05 Mar 2024 10:00:00 +0000", Updated: "2024-03-01T10:00:00Z"}, ""},
+		{"wxr modified", Item{PostDateGMT: "2024-03-05 10:00:00", PostModifiedGMT: "2024-03-06 09:00:00", PostModified: "2024-03-06 10:00:00"}, "lastmod: 2024-03-06T10:00:00+01:00\n"},
+		{"wxr local modified", Item{PostDateGMT: "2024-03-05 10:00:00", PostModified: "2024-03-07 12:00:00"}, "lastmod: 2024-03-07T12:00:00+01:00\n"},
+	}
+	for i, tt := range tests {
+		tt.item.Title = "Post"
+		tt.item.Link = fmt.Sprintf("https://example.com/2024/03/05/post-%d/", i)
+		rec, err := conv.processItem(tt.item, berlin, conv.newDownloader(1, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(data)
+		if tt.want == "" && strings.Contains(got, "lastmod") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: got\n%s", tt.name, got)
+		}
+	}
+}
+
+func TestFrontMatterDateFormat(t *testing.T) {
+	fm := FrontMatter{Title: "Post", Date: time.Date(2024, 3, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600))}
+	tests := []struct {
+		format, layout, utc, want string
+	}{
+		{"yaml", "rfc3339", "false", "date: 2024-03-01T00:30:00+01:00\n"},
+		{"yaml", "rfc3339", "true", "date: 2024-02-29T23:30:00Z\n"},
+		{"yaml", "dateonly", "false", "date: 2024-03-01\n"},
+		{"yaml", "dateonly", "true", "date: 2024-02-29\n"},
+		{"yaml", "2006-01-02T15:04:05", "false", "date: 2024-03-01T00:30:00\n"},
+		{"yaml", "02.01.2006: 15h", "false", "date: '01.03.2024: 00h'\n"},
+		{"toml", "dateonly", "false", "date = 2024-03-01\n"},
+		{"toml", "02.01.2006", "false", "date = \"01.03.2024\"\n"},
+		{"json", "dateonly", "false", "\"date\": \"2024-03-01\","},
+	}
+	for _, tt := range tests {
+		setFlag(t, "frontmatter", tt.format)
+		setFlag(t, "date-format", tt.layout)
+		setFlag(t, "utc", tt.utc)
+		out, err := conv.marshalFrontMatter(fm.toMap(conv.opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), tt.want) {
+			t.Errorf("%s -date-format %q -utc=%s: got\n%s\nwant it to contain %q", tt.format, tt.layout, tt.utc, out, tt.want)
+		}
+	}
+}
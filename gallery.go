@@ -0,0 +1,83 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// defaultGallery renders the -content-max-images overflow gallery without
+// -gallery-shortcode: a list of Markdown images in {{< gallery >}}.
+var defaultGallery = template.Must(template.New("gallery").Delims("[[", "]]").Parse(
+	"{{< gallery >}}\n[[range .Images]][[printf \"![%s](%s)\" .Alt .Src]]\n[[end]]{{< /gallery >}}"))
+
+// galleryImage is one image of a gallery as a -gallery-shortcode sees it.
+type galleryImage struct {
+	Src, Alt, Caption string
+}
+
+// parseGalleryTemplate parses a -gallery-shortcode value, the template text
+// or a file containing it. It uses [[ ]] as delimiters, so Hugo's {{< >}}
+// stay literal.
+func parseGalleryTemplate(src string) (*template.Template, error) {
+	if fileExists(src) {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+		src = string(data)
+	}
+	return template.New("gallery").Delims("[[", "]]").Option("missingkey=error").Parse(src)
+}
+
+// galleryImages collects the images of a gallery with their alt text (else
+// the file name) and the caption of their own <figure>, if any.
+func galleryImages(gallery *goquery.Selection) []galleryImage {
+	var images []galleryImage
+	gallery.Find("img").Each(func(_ int, img *goquery.Selection) {
+		src := strings.TrimSpace(img.AttrOr("src", ""))
+		if src == "" {
+			return
+		}
+		alt := strings.TrimSpace(img.AttrOr("alt", ""))
+		if alt == "" {
+			alt = path.Base(src)
+		}
+		caption := ""
+		if fig := img.Closest("figure"); fig.Length() > 0 && !fig.IsSelection(gallery) {
+			caption = strings.TrimSpace(fig.ChildrenFiltered("figcaption").Text())
+		}
+		images = append(images, galleryImage{Src: src, Alt: alt, Caption: caption})
+	})
+	return images
+}
+
+// galleryMarkdown renders the images of gallery with -gallery-shortcode, or
+// the default {{< gallery >}} list when t is nil.
+func galleryMarkdown(t *template.Template, gallery *goquery.Selection) (string, error) {
+	if t == nil {
+		t = defaultGallery
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, struct{ Images []galleryImage }{galleryImages(gallery)}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// writeGallery appends the gallery's markup and a blank line to b. A failing
+// -gallery-shortcode is logged and the gallery left out.
+func (c *Converter) writeGallery(b *strings.Builder, gallery *goquery.Selection) {
+	g, err := galleryMarkdown(c.galleryTemplate, gallery)
+	if err != nil {
+		c.warnf("-gallery-shortcode: %v", err)
+		return
+	}
+	if g != "" {
+		b.WriteString(g + "\n\n")
+	}
+}
@@ -0,0 +1,43 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestGalleryShortcode(t *testing.T) {
+	in := `<p>Holiday</p><figure class="wp-block-gallery has-nested-images columns-2">` +
+		`<figure class="wp-block-image"><img src="/media/p/001_a.jpg" alt="Beach"><figcaption>Day one</figcaption></figure>` +
+		`<figure class="wp-block-image"><img src="/media/p/002_b.jpg"></figure>` +
+		`<figcaption class="blocks-gallery-caption">The whole trip</figcaption></figure><p>Back home</p>`
+	tests := []struct {
+		name, tmpl, want string
+	}{
+		{"unset", "", "Holiday\n\nBack home"},
+		{"figures", `{{< gallery >}}[[range .Images]]{{< figure src="[[.Src]]" alt=[[printf "%q" .Alt]][[with .Caption]] caption="[[.]]"[[end]] >}}[[end]]{{< /gallery >}}`,
+			"Holiday\n\n{{< gallery >}}{{< figure src=\"/media/p/001_a.jpg\" alt=\"Beach\" caption=\"Day one\" >}}{{< figure src=\"/media/p/002_b.jpg\" alt=\"002_b.jpg\" >}}{{< /gallery >}}\n\nBack home"},
+		{"markdown list", "{{< load-photoswipe >}}\n[[range .Images]][[printf \"![%s](%s)\" .Alt .Src]]\n[[end]]",
+			"Holiday\n\n{{< load-photoswipe >}}\n![Beach](/media/p/001_a.jpg)\n![002_b.jpg](/media/p/002_b.jpg)\n\nBack home"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv.galleryTemplate = nil
+			if tt.tmpl != "" {
+				tmpl, err := parseGalleryTemplate(tt.tmpl)
+				if err != nil {
+					t.Fatal(err)
+				}
+				conv.galleryTemplate = tmpl
+			}
+			defer func() { conv.galleryTemplate = nil }()
+			got, err := conv.toMarkdownPreserveOrder(in, "s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseGalleryTemplate("[[range .Images]]"); err == nil {
+		t.Errorf("unclosed range parsed")
+	}
+}
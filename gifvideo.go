@@ -0,0 +1,76 @@
+package wordpress2hugo
+
+import (
+	"errors"
+	"fmt"
+	"image/gif"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// convertAnimatedGIF downloads the GIF right away and, if it is animated,
+// transcodes it to an MP4 next to it. It returns the MP4 path on success.
+// Static GIFs (and any failure) return ok=false so the caller keeps the GIF.
+func (c *Converter) convertAnimatedGIF(dl *downloader, rawURL, gifDest string) (string, bool) {
+	if c.ffmpegPath == "" || !c.robots.allowed(rawURL) {
+		return "", false // disallowed GIFs are skipped by scheduleMedia
+	}
+	mp4 := strings.TrimSuffix(gifDest, filepath.Ext(gifDest)) + ".mp4"
+	if st, err := os.Stat(mp4); err == nil && st.Size() > 0 {
+		return mp4, true
+	}
+	if err := dl.Fetch(rawURL, gifDest); err != nil {
+		if errors.Is(err, errTooLarge) {
+			return "", false // logged by the downloader
+		}
+		c.warnf("download failed %s -> %s: %v", rawURL, gifDest, err)
+		return "", false
+	}
+	animated, err := isAnimatedGIF(gifDest)
+	if err != nil {
+		c.warnf("inspect gif %s: %v", gifDest, err)
+		return "", false
+	}
+	if !animated {
+		return "", false
+	}
+	if err := c.transcodeGIF(gifDest, mp4); err != nil {
+		c.warnf("gif->mp4 %s: %v", gifDest, err)
+		_ = os.Remove(mp4)
+		return "", false
+	}
+	_ = os.Remove(gifDest)
+	c.debugf("converted %s -> %s", gifDest, mp4)
+	return mp4, true
+}
+
+func isAnimatedGIF(p string) (bool, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return false, err
+	}
+	return len(g.Image) > 1, nil
+}
+
+func (c *Converter) transcodeGIF(src, dest string) error {
+	// yuv420p needs even dimensions; faststart lets browsers begin playback early
+	cmd := exec.Command(c.ffmpegPath, "-y", "-loglevel", "error", "-i", src,
+		"-movflags", "faststart", "-pix_fmt", "yuv420p",
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2", dest)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gifVideoHTML is the markup that replaces a converted GIF: a silent, looping inline video.
+func gifVideoHTML(src string) string {
+	return fmt.Sprintf(`<video src="%s" autoplay loop muted playsinline></video>`, src)
+}
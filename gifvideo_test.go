@@ -0,0 +1,133 @@
+package wordpress2hugo
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeTestGIF writes a 2x2 GIF with the given number of frames.
+func writeTestGIF(t *testing.T, p string, frames int) {
+	t.Helper()
+	pal := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		img := image.NewPaletted(image.Rect(0, 0, 2, 2), pal)
+		img.SetColorIndex(i%2, 0, 1)
+		g.Image = append(g.Image, img)
+		g.Delay = append(g.Delay, 10)
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, g); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsAnimatedGIF(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		want   bool
+	}{
+		{"static", 1, false},
+		{"two frames", 2, true},
+		{"many frames", 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "a.gif")
+			writeTestGIF(t, p, tt.frames)
+			got, err := isAnimatedGIF(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isAnimatedGIF = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGIFToMP4(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the ffmpeg stand-in is a shell script")
+	}
+	dir := t.TempDir()
+	// stands in for ffmpeg: writes the last argument, the output file
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\nfor a; do out=$a; done\necho mp4 > \"$out\"\n"
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestGIF(t, filepath.Join(dir, "anim.gif"), 2)
+	writeTestGIF(t, filepath.Join(dir, "still.gif"), 1)
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	old := conv.ffmpegPath
+	conv.ffmpegPath = ffmpeg
+	t.Cleanup(func() { conv.ffmpegPath = old })
+	setFlag(t, "gif-to-mp4", "true")
+	setFlag(t, "v", "false")
+
+	tests := []struct {
+		name, file, want, wantFile string
+	}{
+		{"animated becomes a video", "anim.gif", `<video src="/media/2024-03-gif/001_anim.mp4"`, "001_anim.mp4"},
+		{"static stays an image", "still.gif", `<img src="/media/2024-03-gif/001_still.gif"`, "001_still.gif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static := t.TempDir()
+			setFlag(t, "static", static)
+			dl := conv.newDownloader(1, 1)
+			html, err := conv.rewriteAndDownloadImages(`<p><img src="`+srv.URL+"/"+tt.file+`"></p>`, nil, "2024-03-gif", dl, &postRecord{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dl.Wait()
+			if !strings.Contains(html, tt.want) {
+				t.Errorf("html lacks %q:\n%s", tt.want, html)
+			}
+			if !fileExists(filepath.Join(static, "media", "2024-03-gif", tt.wantFile)) {
+				t.Errorf("%s not downloaded", tt.wantFile)
+			}
+		})
+	}
+}
+
+// ffmpeg is only looked up (and missed) when GIFs get converted: not in dry
+// runs or with -stdout, which write no media.
+func TestGIFToMP4FFmpegLookup(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	for _, tt := range []struct {
+		dryRun, stdout, warn bool
+	}{{false, false, true}, {true, false, false}, {false, true, false}} {
+		logs.Reset()
+		o := DefaultOptions()
+		o.GIFToMP4, o.DryRun, o.Stdout = true, tt.dryRun, tt.stdout
+		c, err := NewConverter(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+		if got := strings.Contains(logs.String(), "ffmpeg not found"); got != tt.warn {
+			t.Errorf("dry run %v, stdout %v: warned %v\n%s", tt.dryRun, tt.stdout, got, logs.String())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package wordpress2hugo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// parseHeaders parses "Name: Value" pairs; a name may repeat.
+func parseHeaders(srcs []string) (http.Header, error) {
+	h := http.Header{}
+	for _, src := range srcs {
+		// errors don't echo the value, it may be a token
+		name, value, ok := strings.Cut(src, ":")
+		if !ok {
+			return nil, errors.New(`want "Name: Value"`)
+		}
+		if name = strings.TrimSpace(name); name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		h.Add(textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(value))
+	}
+	return h, nil
+}
+
+// addRequestHeaders sets the -user-agent and the -header values on req; a
+// User-Agent passed with -header wins.
+func (c *Converter) addRequestHeaders(req *http.Request) {
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+	for name, values := range c.requestHeaders {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+}
+
+// addFeedAuth prepares a feed request: the -header values and, with
+// -feed-user, HTTP basic auth.
+func (c *Converter) addFeedAuth(req *http.Request) {
+	c.addRequestHeaders(req)
+	if c.opts.FeedUser != "" {
+		req.SetBasicAuth(c.opts.FeedUser, c.opts.FeedPass)
+	}
+}
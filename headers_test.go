@@ -0,0 +1,107 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newImageFeedServer serves a feed with one post embedding /img.png, and the
+// image. guard sees each request first and answers it itself by returning
+// false. The image URL comes from the request's Host, so the handler never
+// reads srv.URL while the server is being set up.
+func newImageFeedServer(t *testing.T, guard func(w http.ResponseWriter, r *http.Request) bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !guard(w, r) {
+			return
+		}
+		if r.URL.Path == "/img.png" {
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprint(w, "png")
+			return
+		}
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>`+
+			`<item><title>Post</title><link>https://example.com/2024/01/02/post/</link><pubDate>Tue, 02 Jan 2024 10:00:00 +0000</pubDate>`+
+			`<content:encoded><![CDATA[<p><img src="http://%s/img.png"></p>]]></content:encoded></item></channel></rss>`, r.Host)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFeedAuthAndHeaders(t *testing.T) {
+	srv := newImageFeedServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("X-Token") != "tok-123" {
+			http.Error(w, "no token", http.StatusForbidden)
+			return false
+		}
+		if user, pass, ok := r.BasicAuth(); r.URL.Path != "/img.png" && (!ok || user != "editor" || pass != "s3cret") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		return true
+	})
+
+	dir := t.TempDir()
+	args := []string{"-feed", srv.URL + "/feed/", "-out", filepath.Join(dir, "content"), "-static", filepath.Join(dir, "static"), "-yes",
+		"-header", "X-Token: tok-123"}
+	if log, err := runMain(t, args...); err == nil || !strings.Contains(log, "HTTP 401") {
+		t.Errorf("run without credentials: err = %v\n%s", err, log)
+	}
+	log, err := runMain(t, append(args, "-feed-user", "editor", "-feed-pass", "s3cret")...)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "static", "media", "2024-01-post", "001_img.png")); err != nil || string(got) != "png" {
+		t.Errorf("image = %q, %v", got, err)
+	}
+	if strings.Contains(log, "s3cret") || strings.Contains(log, "tok-123") {
+		t.Errorf("credentials logged:\n%s", log)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	h, err := parseHeaders([]string{"x-token: a", "Accept-Language:de", "X-Token: b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Values("X-Token"); len(got) != 2 || got[0] != "a" || got[1] != "b" || h.Get("Accept-Language") != "de" {
+		t.Errorf("headers = %v", h)
+	}
+	for _, bad := range []string{"no-colon-secret", ": value", "Bad Name: v"} {
+		_, err := parseHeaders([]string{bad})
+		if err == nil {
+			t.Errorf("%q accepted", bad)
+		} else if strings.Contains(err.Error(), "secret") {
+			t.Errorf("%q: value in error %v", bad, err)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var mu sync.Mutex
+	agents := map[string]string{}
+	srv := newImageFeedServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		mu.Lock()
+		agents[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		return true
+	})
+
+	dir := t.TempDir()
+	const ua = "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"
+	log, err := runMain(t, "-feed", srv.URL+"/feed/", "-out", filepath.Join(dir, "content"), "-static", filepath.Join(dir, "static"), "-yes",
+		"-user-agent", ua)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	for _, p := range []string{"/feed/", "/img.png"} {
+		if agents[p] != ua {
+			t.Errorf("%s: User-Agent = %q", p, agents[p])
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package wordpress2hugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// hugoConfig holds the few site settings (-hugo-config) that decide whether
+// the generated files fit into the Hugo site.
+type hugoConfig struct {
+	dir        string            // site root (directory of the config file)
+	contentDir string            // default "content"
+	staticDir  string            // default "static"
+	permalinks map[string]string // section -> pattern
+	taxonomies map[string]string // singular -> plural; nil = Hugo defaults
+}
+
+// hugoConfigNames are tried in order when -hugo-config points at a directory.
+var hugoConfigNames = []string{
+	"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+	"config.toml", "config.yaml", "config.yml", "config.json",
+}
+
+func loadHugoConfig(p string) (*hugoConfig, error) {
+	if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+		found := ""
+		for _, name := range hugoConfigNames {
+			if _, err := os.Stat(filepath.Join(p, name)); err == nil {
+				found = filepath.Join(p, name)
+				break
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("no hugo.toml/yaml/json or config.toml/yaml/json in %s", p)
+		}
+		p = found
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unknown config format %q (want .toml, .yaml or .json)", filepath.Ext(p))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", p, err)
+	}
+	raw = lowerKeys(raw)
+
+	cfg := &hugoConfig{dir: filepath.Dir(p), contentDir: "content", staticDir: "static"}
+	if s, ok := raw["contentdir"].(string); ok && s != "" {
+		cfg.contentDir = s
+	}
+	if s, ok := raw["staticdir"].(string); ok && s != "" {
+		cfg.staticDir = s
+	}
+	if m, ok := raw["permalinks"].(map[string]any); ok {
+		// Hugo >= 0.112 nests section patterns under permalinks.page
+		if page, ok := m["page"].(map[string]any); ok {
+			m = page
+		}
+		cfg.permalinks = stringMap(m)
+	}
+	if m, ok := raw["taxonomies"].(map[string]any); ok {
+		cfg.taxonomies = stringMap(m)
+	}
+	return cfg, nil
+}
+
+// checkHugoConfig compares cfg with what this run is going to write and
+// returns a warning for each mismatch. taxonomyKeys are the front matter keys
+// of the taxonomy lists; datedNames tells whether the file names contain the
+// date (no -slug-format, or one using .Year/.Month).
+func checkHugoConfig(cfg *hugoConfig, outDir, staticDir string, taxonomyKeys []string, datedNames bool) []string {
+	var warns []string
+
+	contentRoot := filepath.Join(cfg.dir, cfg.contentDir)
+	section := ""
+	if rel, ok := within(contentRoot, outDir); !ok {
+		warns = append(warns, fmt.Sprintf("-out %s is not inside the site's contentDir %s; set -out to a folder below it", outDir, contentRoot))
+	} else if rel != "." {
+		section = strings.Split(filepath.ToSlash(rel), "/")[0]
+	}
+	staticRoot := filepath.Join(cfg.dir, cfg.staticDir)
+	if _, ok := within(staticRoot, staticDir); !ok {
+		warns = append(warns, fmt.Sprintf("-static %s is not the site's staticDir %s; the /media links will not resolve", staticDir, staticRoot))
+	}
+
+	for _, key := range taxonomyKeys {
+		if strings.Contains(key, ".") {
+			warns = append(warns, fmt.Sprintf("front matter key %q is nested; Hugo only builds taxonomies from top-level keys", key))
+			continue
+		}
+		if !cfg.hasTaxonomy(key) {
+			warns = append(warns, fmt.Sprintf("taxonomies does not define %q; add %s = %q under [taxonomies] or change -tags-key/-categories-key/-source-key", key, singular(key), key))
+		}
+	}
+
+	if pattern, ok := cfg.permalinks[section]; ok && section != "" {
+		if strings.Contains(pattern, ":title") {
+			warns = append(warns, fmt.Sprintf("permalinks.%s = %q uses :title, which may differ from the WordPress slug; use :slug instead", section, pattern))
+		}
+		if datedNames && (strings.Contains(pattern, ":filename") || strings.Contains(pattern, ":contentbasename")) &&
+			(strings.Contains(pattern, ":year") || strings.Contains(pattern, ":month")) {
+			warns = append(warns, fmt.Sprintf("permalinks.%s = %q repeats the date: file names already contain it (see -slug-format)", section, pattern))
+		}
+	}
+	return warns
+}
+
+func (c *hugoConfig) hasTaxonomy(plural string) bool {
+	if c.taxonomies == nil {
+		return plural == "tags" || plural == "categories"
+	}
+	for _, p := range c.taxonomies {
+		if strings.EqualFold(p, plural) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether p lies inside root and returns the relative path.
+func within(root, p string) (string, bool) {
+	absRoot, err1 := filepath.Abs(root)
+	absP, err2 := filepath.Abs(p)
+	if err1 != nil || err2 != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(absRoot, absP)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+func singular(plural string) string {
+	switch {
+	case strings.HasSuffix(plural, "ies"):
+		return strings.TrimSuffix(plural, "ies") + "y"
+	case strings.HasSuffix(plural, "s"):
+		return strings.TrimSuffix(plural, "s")
+	}
+	return plural
+}
+
+// lowerKeys lowercases map keys, as Hugo treats config keys case-insensitively.
+func lowerKeys(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+func stringMap(m map[string]any) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[strings.ToLower(k)] = s
+		}
+	}
+	return out
+}
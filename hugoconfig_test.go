@@ -0,0 +1,56 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckHugoConfigTaxonomyMismatch(t *testing.T) {
+	site := t.TempDir()
+	cfgPath := filepath.Join(site, "hugo.toml")
+	cfg := `baseURL = "https://example.com/"
+[taxonomies]
+  tag = "tags"
+  series = "series"
+[permalinks]
+  posts = "/:year/:month/:filename/"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hc, err := loadHugoConfig(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warns := checkHugoConfig(hc, filepath.Join(site, "content", "posts"), filepath.Join(site, "static"), []string{"tags", "categories"}, true)
+	if len(warns) != 2 {
+		t.Fatalf("got %d warnings, want 2: %q", len(warns), warns)
+	}
+	if !strings.Contains(warns[0], `taxonomies does not define "categories"`) || !strings.Contains(warns[0], `category = "categories"`) {
+		t.Errorf("taxonomy warning = %q", warns[0])
+	}
+	if !strings.Contains(warns[1], "permalinks.posts") || !strings.Contains(warns[1], "repeats the date") {
+		t.Errorf("permalink warning = %q", warns[1])
+	}
+}
+
+func TestCheckHugoConfigDefaults(t *testing.T) {
+	site := t.TempDir()
+	cfgPath := filepath.Join(site, "hugo.yaml")
+	if err := os.WriteFile(cfgPath, []byte("baseURL: https://example.com/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hc, err := loadHugoConfig(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warns := checkHugoConfig(hc, filepath.Join(site, "content", "posts"), filepath.Join(site, "static"), []string{"tags", "categories"}, true); len(warns) != 0 {
+		t.Errorf("unexpected warnings: %q", warns)
+	}
+	warns := checkHugoConfig(hc, filepath.Join(site, "posts"), filepath.Join(site, "static"), []string{"params.topics", "categories"}, true)
+	if len(warns) != 2 || !strings.Contains(warns[0], "contentDir") || !strings.Contains(warns[1], "nested") {
+		t.Errorf("warnings = %q", warns)
+	}
+}
@@ -0,0 +1,80 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// writeIndex writes a Markdown overview of the imported posts, grouped by
+// year (in the order the years first appear) or by category (alphabetical).
+// Posts are linked with Hugo's ref shortcode, so the page must live in content/.
+func (c *Converter) writeIndex(path string, recs []*postRecord, groupBy string) error {
+	var order []string
+	groups := map[string][]*postRecord{}
+	add := func(key string, rec *postRecord) {
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], rec)
+	}
+	for _, rec := range recs {
+		if rec.File == "" {
+			continue
+		}
+		switch groupBy {
+		case "category":
+			if len(rec.Categories) == 0 {
+				add("Uncategorized", rec)
+			}
+			for _, c := range rec.Categories {
+				add(c, rec)
+			}
+		default:
+			year := "Undated"
+			if !rec.Date.IsZero() {
+				year = fmt.Sprintf("%04d", rec.Date.Year())
+			}
+			add(year, rec)
+		}
+	}
+	if groupBy == "category" {
+		sort.Strings(order)
+	}
+
+	fm := newFMMap()
+	fm.Set("title", "Imported posts")
+	data, err := c.marshalFrontMatter(fm)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.Write(data)
+	for _, key := range order {
+		fmt.Fprintf(&buf, "\n## %s\n\n", key)
+		for _, rec := range groups[key] {
+			date := ""
+			if !rec.Date.IsZero() {
+				date = rec.Date.Format("2006-01-02") + " – "
+			}
+			title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(rec.Title)
+			fmt.Fprintf(&buf, "- %s[%s]({{< ref %q >}})\n", date, title, refName(rec.File))
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// refName is how the index refers to a post: its file name, or the bundle
+// folder's name for an index.md (-bundles).
+func refName(file string) string {
+	if strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) == "index" {
+		return filepath.Base(filepath.Dir(file))
+	}
+	return filepath.Base(file)
+}
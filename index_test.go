@@ -0,0 +1,50 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputIndex(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel>` +
+		`<item><title>Newest</title><link>https://example.com/2024/02/01/newest/</link><pubDate>Thu, 01 Feb 2024 10:00:00 +0000</pubDate><category>Travel</category><description>a</description></item>` +
+		`<item><title>Middle [draft]</title><link>https://example.com/2024/01/15/middle/</link><pubDate>Mon, 15 Jan 2024 10:00:00 +0000</pubDate><description>b</description></item>` +
+		`<item><title>Oldest</title><link>https://example.com/2023/12/24/oldest/</link><pubDate>Sun, 24 Dec 2023 10:00:00 +0000</pubDate><category>Travel</category><description>c</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	index := filepath.Join(dir, "content", "imported.md")
+	args := []string{"-feed", feedPath, "-out", filepath.Join(dir, "content", "posts"), "-static", filepath.Join(dir, "static"),
+		"-limit", "0", "-yes", "-output-index", index, "-tz", "UTC"}
+
+	tests := []struct {
+		group, want string
+	}{
+		{"year", "---\ntitle: Imported posts\n---\n\n## 2024\n\n" +
+			"- 2024-02-01 – [Newest]({{< ref \"2024-02-newest.md\" >}})\n" +
+			"- 2024-01-15 – [Middle \\[draft\\]]({{< ref \"2024-01-middle.md\" >}})\n\n## 2023\n\n" +
+			"- 2023-12-24 – [Oldest]({{< ref \"2023-12-oldest.md\" >}})\n"},
+		{"category", "---\ntitle: Imported posts\n---\n\n## Travel\n\n" +
+			"- 2024-02-01 – [Newest]({{< ref \"2024-02-newest.md\" >}})\n" +
+			"- 2023-12-24 – [Oldest]({{< ref \"2023-12-oldest.md\" >}})\n\n## Uncategorized\n\n" +
+			"- 2024-01-15 – [Middle \\[draft\\]]({{< ref \"2024-01-middle.md\" >}})\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			if log, err := runMain(t, append(args, "-index-group", tt.group)...); err != nil {
+				t.Fatalf("%v\n%s", err, log)
+			}
+			data, err := os.ReadFile(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Links between imported posts (-internal-links) can only be rewritten once
+// every post has its file, so they are fixed in a pass over the written pages
+// after the run, like the media of -dedupe-media.
+
+// linkTargetRe matches the target of a Markdown link or an href attribute.
+var linkTargetRe = regexp.MustCompile(`(\]\(|href=")([^\s)"]+)`)
+
+// internalLinks maps the old URLs of the imported posts to their pages.
+type internalLinks struct {
+	hosts  map[string]bool        // hosts of the old post links, without www.
+	byPath map[string]*postRecord // old permalink paths and aliases
+}
+
+func newInternalLinks(records []*postRecord) *internalLinks {
+	l := &internalLinks{hosts: map[string]bool{}, byPath: map[string]*postRecord{}}
+	for _, rec := range records {
+		if rec.File == "" {
+			continue
+		}
+		if u, err := url.Parse(rec.Link); err == nil && rec.Link != "" {
+			l.hosts[linkHost(u)] = true
+			l.byPath[ensureTrailingSlash(u.Path)] = rec
+		}
+		for _, a := range rec.redirectPaths() {
+			if _, taken := l.byPath[a]; !taken {
+				l.byPath[a] = rec
+			}
+		}
+	}
+	return l
+}
+
+func linkHost(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// target returns the post an href points to: an absolute URL on one of the
+// blog's hosts, or a root-relative path.
+func (l *internalLinks) target(href string) (*postRecord, string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Path == "" || !strings.HasPrefix(u.Path, "/") {
+		return nil, "", false
+	}
+	if u.IsAbs() || u.Host != "" {
+		if (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") || !l.hosts[linkHost(u)] {
+			return nil, "", false
+		}
+	}
+	if id := shortlinkID(href); id != "" { // -alias-shortlink
+		if rec, ok := l.byPath["/?p="+id]; ok {
+			return rec, u.Fragment, true
+		}
+	}
+	rec, ok := l.byPath[ensureTrailingSlash(u.Path)]
+	return rec, u.Fragment, ok
+}
+
+// internalLinkTarget is the new link target per -internal-links.
+func (c *Converter) internalLinkTarget(rec *postRecord, fragment string) string {
+	if fragment != "" {
+		fragment = "#" + fragment
+	}
+	if c.opts.InternalLinks == "path" {
+		return c.pagePath(rec.File) + fragment
+	}
+	ref := "/" + c.contentPath(rec.File)
+	if path.Base(ref) == "index."+c.opts.ContentFormat { // leaf bundle (-bundles)
+		ref = path.Dir(ref)
+	}
+	return fmt.Sprintf(`{{< ref "%s%s" >}}`, ref, fragment)
+}
+
+// rewriteInternalLinks points links between the imported posts at their new
+// pages. Pages kept by -overwrite=false are left alone.
+func (c *Converter) rewriteInternalLinks(records []*postRecord) (int, error) {
+	links := newInternalLinks(records)
+	n := 0
+	for _, rec := range records {
+		if rec.File == "" || rec.preserved {
+			continue
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			return n, err
+		}
+		changed := 0
+		out := linkTargetRe.ReplaceAllStringFunc(string(data), func(m string) string {
+			sub := linkTargetRe.FindStringSubmatch(m)
+			target, fragment, ok := links.target(sub[2])
+			if !ok {
+				return m
+			}
+			changed++
+			return sub[1] + c.internalLinkTarget(target, fragment)
+		})
+		if changed == 0 {
+			continue
+		}
+		if err := os.WriteFile(rec.File, []byte(out), 0o644); err != nil {
+			return n, err
+		}
+		n += changed
+	}
+	return n, nil
+}
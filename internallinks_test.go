@@ -0,0 +1,61 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInternalLinks(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link><content:encoded><![CDATA[` +
+		`<p>See <a href="https://www.example.com/2024/01/15/second/#part-2">the second post</a>, ` +
+		`<a href="/2024/01/15/second">again</a> and <a href="https://other.example/2024/01/15/second/">elsewhere</a>.</p>` +
+		`]]></content:encoded></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link><content:encoded><![CDATA[<p>Back to <a href="https://example.com/2024/02/01/first/">first</a>.</p>]]></content:encoded></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mode, format string
+		first, back  []string
+	}{
+		{"keep", "html", []string{`href="https://www.example.com/2024/01/15/second/#part-2"`, `href="/2024/01/15/second"`}, []string{`href="https://example.com/2024/02/01/first/"`}},
+		{"ref", "html", []string{`href="{{< ref "/posts/2024-01-second.html#part-2" >}}"`, `href="{{< ref "/posts/2024-01-second.html" >}}"`}, []string{`href="{{< ref "/posts/2024-02-first.html" >}}"`}},
+		{"path", "html", []string{`href="/posts/2024-01-second/#part-2"`, `href="/posts/2024-01-second/"`}, []string{`href="/posts/2024-02-first/"`}},
+		{"keep", "md", []string{`[the second post](https://www.example.com/2024/01/15/second/#part-2)`, `[again](/2024/01/15/second)`}, []string{`[first](https://example.com/2024/02/01/first/)`}},
+		{"ref", "md", []string{`[the second post]({{< ref "/posts/2024-01-second.md#part-2" >}})`, `[again]({{< ref "/posts/2024-01-second.md" >}})`}, []string{`[first]({{< ref "/posts/2024-02-first.md" >}})`}},
+		{"path", "md", []string{`[the second post](/posts/2024-01-second/#part-2)`, `[again](/posts/2024-01-second/)`}, []string{`[first](/posts/2024-02-first/)`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"-"+tt.format, func(t *testing.T) {
+			base := filepath.Join(dir, tt.mode+"-"+tt.format)
+			out := filepath.Join(base, "content", "posts")
+			log, err := runMain(t, "-feed", feedPath, "-out", out, "-static", filepath.Join(base, "static"),
+				"-limit", "0", "-content-format", tt.format, "-internal-links", tt.mode)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, log)
+			}
+			check := func(name string, want []string) {
+				data, err := os.ReadFile(filepath.Join(out, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, w := range want {
+					if !strings.Contains(string(data), w) {
+						t.Errorf("%s does not contain %s:\n%s", name, w, data)
+					}
+				}
+				if !strings.Contains(string(data), "other.example") && strings.HasPrefix(name, "2024-02-first.") {
+					t.Errorf("external link changed:\n%s", data)
+				}
+			}
+			check("2024-02-first."+tt.format, tt.first)
+			check("2024-01-second."+tt.format, tt.back)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package wordpress2hugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logLevel orders the log messages from the most to the least important.
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var levelNames = []string{"error", "warn", "info", "debug"}
+
+// logMu keeps the JSON lines of concurrent messages whole.
+var logMu sync.Mutex
+
+// logger writes the messages of a Converter to the log output. Messages above
+// threshold (-log-level) are dropped; with json (-log-json) each message is
+// one JSON object per line.
+type logger struct {
+	threshold logLevel
+	json      bool
+
+	posMu sync.Mutex
+	pos   string // "i/n " of the item Run is converting, see setItemPos
+}
+
+// parseLogLevel reads a -log-level. An empty name follows -v: debug when
+// verbose, else info.
+func parseLogLevel(name string, verbose bool) (logLevel, error) {
+	if name == "" {
+		if verbose {
+			return levelDebug, nil
+		}
+		return levelInfo, nil
+	}
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return logLevel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("must be error, warn, info or debug, got %q", name)
+}
+
+// setItemPos sets the "i/n " of the item Run is converting; "" ends it. logf
+// prefixes it to every message while set, so long imports show how far
+// along they are and warnings say which item they came from.
+func (lg *logger) setItemPos(pos string) {
+	lg.posMu.Lock()
+	lg.pos = pos
+	lg.posMu.Unlock()
+}
+
+// logf writes a message at level l to the log output: prefixed with the
+// level (info has none) like the log package does, or as JSON.
+func (lg *logger) logf(l logLevel, format string, args ...any) {
+	lg.write(l, fmt.Sprintf(format, args...))
+}
+
+// logFields writes msg at level l with key/value pairs: appended as key=value
+// in text, as their own keys in JSON, so tools can read them apart.
+func (lg *logger) logFields(l logLevel, msg string, kv ...string) {
+	lg.write(l, msg, kv...)
+}
+
+func (lg *logger) write(l logLevel, msg string, kv ...string) {
+	if l > lg.threshold {
+		return
+	}
+	lg.posMu.Lock()
+	msg = lg.pos + msg
+	lg.posMu.Unlock()
+	if !lg.json {
+		if l != levelInfo {
+			msg = levelNames[l] + ": " + msg
+		}
+		for i := 0; i+1 < len(kv); i += 2 {
+			v := kv[i+1]
+			if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+				v = strconv.Quote(v)
+			}
+			msg += " " + kv[i] + "=" + v
+		}
+		log.Print(msg)
+		return
+	}
+	line, _ := json.Marshal(struct {
+		Time  string `json:"time"`
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}{time.Now().Format(time.RFC3339), levelNames[l], msg})
+	line = line[:len(line)-1]
+	for i := 0; i+1 < len(kv); i += 2 {
+		k, _ := json.Marshal(kv[i])
+		v, _ := json.Marshal(kv[i+1])
+		line = append(append(append(append(line, ','), k...), ':'), v...)
+	}
+	logMu.Lock()
+	log.Writer().Write(append(line, '}', '\n'))
+	logMu.Unlock()
+}
+
+func (lg *logger) errorf(format string, args ...any) { lg.logf(levelError, format, args...) }
+func (lg *logger) warnf(format string, args ...any)  { lg.logf(levelWarn, format, args...) }
+func (lg *logger) infof(format string, args ...any)  { lg.logf(levelInfo, format, args...) }
+func (lg *logger) debugf(format string, args ...any) { lg.logf(levelDebug, format, args...) }
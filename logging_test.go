@@ -0,0 +1,126 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		verbose bool
+		want    logLevel
+	}{
+		{"", true, levelDebug},
+		{"", false, levelInfo},
+		{"warn", true, levelWarn},
+		{"ERROR", false, levelError},
+		{"debug", false, levelDebug},
+	}
+	for _, tt := range tests {
+		if got, err := parseLogLevel(tt.name, tt.verbose); err != nil || got != tt.want {
+			t.Errorf("parseLogLevel(%q, %v) = %v, %v, want %v", tt.name, tt.verbose, got, err, tt.want)
+		}
+	}
+	if _, err := parseLogLevel("verbose", true); err == nil {
+		t.Error("unknown level accepted")
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	defer func(l logLevel, j bool) { conv.threshold, conv.json = l, j }(conv.threshold, conv.json)
+
+	conv.threshold = levelInfo
+	conv.warnf("download failed %s", "a.jpg")
+	conv.infof("✓ %s", "First")
+	conv.debugf("fallback slug logic for link=%s", "x")
+	out := logs.String()
+	if !strings.Contains(out, "warn: download failed a.jpg\n") || !strings.Contains(out, " ✓ First\n") {
+		t.Errorf("missing messages:\n%s", out)
+	}
+	if strings.Contains(out, "fallback") {
+		t.Errorf("debug message logged at info:\n%s", out)
+	}
+
+	logs.Reset()
+	conv.threshold, conv.json = levelWarn, true
+	conv.infof("✓ %s", "First")
+	conv.errorf("processing item %d: %v", 3, "boom")
+	var rec struct{ Time, Level, Msg string }
+	if err := json.Unmarshal(logs.Bytes(), &rec); err != nil {
+		t.Fatalf("not one JSON line: %v\n%s", err, logs.String())
+	}
+	if rec.Level != "error" || rec.Msg != "processing item 3: boom" || rec.Time == "" {
+		t.Errorf("got %+v", rec)
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	defer func(l logLevel, j bool) { conv.threshold, conv.json = l, j }(conv.threshold, conv.json)
+
+	conv.threshold, conv.json = levelInfo, false
+	conv.logFields(levelError, "download failed", "url", "https://example.com/a.jpg", "err", "HTTP 404 Not Found")
+	if want := `error: download failed url=https://example.com/a.jpg err="HTTP 404 Not Found"` + "\n"; !strings.HasSuffix(logs.String(), want) {
+		t.Errorf("got %q, want suffix %q", logs.String(), want)
+	}
+
+	logs.Reset()
+	conv.json = true
+	conv.logFields(levelError, "download failed", "url", "https://example.com/a.jpg", "err", `bad "quote"`)
+	var rec map[string]string
+	if err := json.Unmarshal(logs.Bytes(), &rec); err != nil {
+		t.Fatalf("not one JSON line: %v\n%s", err, logs.String())
+	}
+	if rec["level"] != "error" || rec["msg"] != "download failed" || rec["url"] != "https://example.com/a.jpg" || rec["err"] != `bad "quote"` {
+		t.Errorf("got %v", rec)
+	}
+}
+
+// With -log-json everything the run writes to stderr is a JSON record, the
+// failed downloads and the -timing summary included.
+func TestLogJSONRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>Post</title><link>https://example.com/2024/01/02/post/</link><pubDate>Tue, 02 Jan 2024 10:00:00 +0000</pubDate>` +
+		`<content:encoded><![CDATA[<p><img src="` + srv.URL + `/missing.png"></p>]]></content:encoded></item></channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(dir, "content"), "-static", filepath.Join(dir, "static"),
+		"-yes", "-v", "-timing", "-log-json")
+	if err == nil {
+		t.Fatalf("run with a failed download succeeded:\n%s", out)
+	}
+	var failed int
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var rec map[string]string
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Errorf("not a JSON line: %q", line)
+			continue
+		}
+		if rec["msg"] == "download failed" && rec["url"] == srv.URL+"/missing.png" && rec["dest"] != "" && rec["err"] != "" {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d download failed records:\n%s", failed, out)
+	}
+}
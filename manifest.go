@@ -0,0 +1,159 @@
+package wordpress2hugo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The report is a JSON manifest of the run. It is rewritten after every item,
+// so an interrupted run leaves a usable checkpoint behind for -resume.
+
+const (
+	assetPending = "pending"
+	assetOK      = "ok"
+	assetFailed  = "failed"
+)
+
+type assetRecord struct {
+	URL    string `json:"url"`
+	Dest   string `json:"dest"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type postRecord struct {
+	ID         string        `json:"id"`
+	Title      string        `json:"title"`
+	Link       string        `json:"link"`
+	File       string        `json:"file"`
+	Date       time.Time     `json:"date"`
+	Tags       []string      `json:"tags,omitempty"`
+	Categories []string      `json:"categories,omitempty"`
+	Aliases    []string      `json:"aliases,omitempty"`
+	Shortlink  string        `json:"shortlink,omitempty"` // /?p=ID, see shortlink
+	Assets     []assetRecord `json:"assets,omitempty"`
+	Indexes    []string      `json:"indexes,omitempty"` // section _index.md files of the post
+
+	preserved bool // file kept as it was by -overwrite=false
+}
+
+// redirectPaths are the aliases plus the -alias-shortlink, the old paths
+// -redirects, -urlmap and -internal-links send to the post.
+func (r *postRecord) redirectPaths() []string {
+	paths := append([]string(nil), r.Aliases...)
+	if r.Shortlink != "" {
+		paths = append(paths, r.Shortlink)
+	}
+	return paths
+}
+
+func (r *postRecord) addAsset(rawURL, dest string) {
+	for _, a := range r.Assets {
+		if a.URL == rawURL && a.Dest == dest {
+			return
+		}
+	}
+	r.Assets = append(r.Assets, assetRecord{URL: rawURL, Dest: dest, Status: assetPending})
+}
+
+// complete reports whether the item's markdown and all of its media are on disk.
+func (r *postRecord) complete() bool {
+	if !fileExists(r.File) {
+		return false
+	}
+	for _, a := range r.Assets {
+		if a.Status != assetOK || !fileExists(a.Dest) {
+			return false
+		}
+	}
+	return true
+}
+
+// itemID identifies an item across runs: GUID when present, otherwise the link.
+func itemID(item Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	return item.Link
+}
+
+type report struct {
+	mu      sync.Mutex
+	path    string
+	records []*postRecord
+}
+
+func newReport(path string) *report {
+	return &report{path: path}
+}
+
+func (r *report) add(rec *postRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+}
+
+// save refreshes asset statuses from the downloader and writes the manifest.
+// It is a no-op when no report path is configured.
+func (r *report) save(dl *downloader) error {
+	if r.path == "" {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range r.records {
+		for i := range rec.Assets {
+			a := &rec.Assets[i]
+			if a.Status == assetOK {
+				continue
+			}
+			done, err := dl.ResultAt(a.URL, a.Dest)
+			switch {
+			case !done:
+				a.Status = assetPending
+			case err != nil:
+				a.Status, a.Error = assetFailed, err.Error()
+			default:
+				a.Status, a.Error = assetOK, ""
+			}
+		}
+	}
+	data, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// loadReport reads a previous manifest, keyed by item ID. A missing file is
+// not an error (nothing to resume from).
+func loadReport(path string) (map[string]*postRecord, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]*postRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recs []*postRecord
+	if err := json.Unmarshal(data, &recs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	out := make(map[string]*postRecord, len(recs))
+	for _, rec := range recs {
+		out[rec.ID] = rec
+	}
+	return out, nil
+}
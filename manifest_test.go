@@ -0,0 +1,179 @@
+package wordpress2hugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeSkipsCompletePosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+
+	// three posts with one image each; brokenPost's image is missing on
+	// the server in the first run
+	feed := func(brokenPost int) string {
+		var items []string
+		for i := 1; i <= 3; i++ {
+			img := srv.URL + "/img-" + strconv.Itoa(i) + ".png"
+			if i == brokenPost {
+				img = srv.URL + "/missing.png"
+			}
+			items = append(items, fmt.Sprintf(`<item><title>Post %[1]d</title><link>https://example.com/2024/01/02/post-%[1]d/</link>`+
+				`<guid>p%[1]d</guid><content:encoded><![CDATA[<p><img src="%[2]s"></p>]]></content:encoded></item>`, i, img))
+		}
+		return `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+			strings.Join(items, "") + `</channel></rss>`
+	}
+
+	tests := []struct {
+		name        string
+		interrupted int   // posts written before the interruption
+		broken      int   // post whose image failed in the first run (0 = none)
+		rewritten   []int // posts the resumed run writes again
+	}{
+		{"nothing done", 0, 0, []int{1, 2, 3}},
+		{"partly done", 2, 0, []int{3}},
+		{"all done", 3, 0, nil},
+		{"image failed", 3, 2, []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			feedPath := filepath.Join(dir, "feed.xml")
+			out := filepath.Join(dir, "content", "posts")
+			args := []string{"-feed", feedPath, "-out", out, "-static", filepath.Join(dir, "static"), "-report", filepath.Join(dir, "report.json"), "-yes"}
+
+			if tt.interrupted > 0 {
+				if err := os.WriteFile(feedPath, []byte(feed(tt.broken)), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				// a failed download makes the run exit non-zero
+				log, err := runMain(t, append(args, "-limit", strconv.Itoa(tt.interrupted))...)
+				if (err != nil) != (tt.broken > 0) {
+					t.Fatalf("first run: %v\n%s", err, log)
+				}
+				if tt.broken > 0 && !strings.Contains(log, "error: download failed url=") {
+					t.Errorf("no failure summary:\n%s", log)
+				}
+			}
+			// mark the finished posts, so a rewrite shows
+			done, _ := filepath.Glob(filepath.Join(out, "*.md"))
+			for _, p := range done {
+				if err := os.WriteFile(p, []byte("done before the interruption"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the image is back for the resumed run
+			if err := os.WriteFile(feedPath, []byte(feed(0)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if log, err := runMain(t, append(args, "-limit", "0", "-resume")...); err != nil {
+				t.Fatalf("resumed run: %v\n%s", err, log)
+			}
+			for i := 1; i <= 3; i++ {
+				data, err := os.ReadFile(filepath.Join(out, fmt.Sprintf("2024-01-post-%d.md", i)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				rewritten := string(data) != "done before the interruption"
+				want := false
+				for _, r := range tt.rewritten {
+					want = want || r == i
+				}
+				if rewritten != want {
+					t.Errorf("post %d: rewritten = %v, want %v", i, rewritten, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReportRecord(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, "out", dir)
+	setFlag(t, "v", "false")
+	item := Item{
+		Title: "Post", Link: "https://example.com/2024/03/05/post/", GUID: "p1",
+		PubDate:    "Tue, 05 Mar 2024 10:00:00 +0000",
+		Categories: []Category{{Value: "go", Domain: "post_tag"}, {Value: "Dev", Domain: "category"}},
+	}
+	dl := conv.newDownloader(1, 1)
+	rec, err := conv.processItem(item, time.UTC, dl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := newReport(filepath.Join(dir, "report.json"))
+	rep.add(rec)
+	if err := rep.save(dl); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rep.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []postRecord
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Title != "Post" || got[0].Link != item.Link || got[0].File != rec.File ||
+		!reflect.DeepEqual(got[0].Tags, []string{"go"}) || !reflect.DeepEqual(got[0].Categories, []string{"Dev"}) {
+		t.Errorf("report = %s", data)
+	}
+}
+
+func TestReportSharedImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+	out, static := t.TempDir(), t.TempDir()
+	setFlag(t, "out", out)
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+
+	dl := conv.newDownloader(2, 2)
+	rep := newReport(filepath.Join(out, "report.json"))
+	for _, slug := range []string{"one", "two"} {
+		item := Item{Title: slug, Link: "https://example.com/2024/03/05/" + slug + "/", GUID: slug,
+			PubDate:        "Tue, 05 Mar 2024 10:00:00 +0000",
+			ContentEncoded: `<p><img src="` + srv.URL + `/shared.png"></p>`}
+		rec, err := conv.processItem(item, time.UTC, dl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rep.add(rec)
+	}
+	dl.Wait()
+	if err := rep.save(dl); err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range rep.records {
+		if len(rec.Assets) != 1 || rec.Assets[0].Status != assetOK || !fileExists(rec.Assets[0].Dest) {
+			t.Errorf("%s: assets = %+v", rec.File, rec.Assets)
+		}
+		if !rec.complete() {
+			t.Errorf("%s not complete", rec.File)
+		}
+	}
+	if !fileExists(filepath.Join(static, "media", "2024-03-two", "001_shared.png")) {
+		t.Error("second post's copy missing")
+	}
+}
@@ -0,0 +1,220 @@
+package wordpress2hugo
+
+import (
+	"flag"
+	"time"
+)
+
+// Options are the settings of a conversion, one field per command-line flag
+// of the same name (see RegisterFlags for what each does). The zero value is
+// not useful; start from DefaultOptions.
+type Options struct {
+	Feed                   string        // -feed
+	FeedUser               string        // -feed-user
+	FeedPass               string        // -feed-pass
+	Paginate               bool          // -paginate
+	MaxPages               int           // -max-pages
+	Source                 string        // -source
+	Out                    string        // -out
+	Static                 string        // -static
+	Timezone               string        // -tz
+	Limit                  int           // -limit
+	Concurrency            int           // -concurrency
+	Timeout                int           // -timeout, in seconds
+	Retries                int           // -retries
+	PerHost                int           // -perhost
+	Rate                   float64       // -rate
+	Delay                  time.Duration // -delay
+	Progress               time.Duration // -progress
+	RespectRobots          bool          // -respect-robots
+	Verbose                bool          // -v
+	Clean                  bool          // -clean
+	Force                  bool          // -force
+	Yes                    bool          // -yes
+	TagsKey                string        // -tags-key
+	CategoriesKey          string        // -categories-key
+	SourceNames            string        // -source-name
+	SourceKey              string        // -source-key
+	FeedState              string        // -feed-state
+	Report                 string        // -report
+	SummaryLength          int           // -summary-length
+	ReadingTime            bool          // -reading-time
+	ReadingTimeSkipCode    bool          // -reading-time-skip-code
+	Canonical              bool          // -canonical
+	Bundles                bool          // -bundles
+	SkipExisting           bool          // -skip-existing
+	CacheDir               string        // -cache-dir
+	Resume                 bool          // -resume
+	GIFToMP4               bool          // -gif-to-mp4
+	ContentMaxImages       int           // -content-max-images
+	GalleryShortcode       string        // -gallery-shortcode
+	DedupeMedia            bool          // -dedupe-media
+	MaxImageBytes          int64         // -max-image-bytes
+	SlugFormat             string        // -slug-format
+	FrontMatterFormat      string        // -frontmatter
+	HugoConfig             string        // -hugo-config
+	DryRun                 bool          // -dry-run
+	Stdout                 bool          // -stdout
+	Timing                 bool          // -timing
+	KeepOriginalFilenames  bool          // -keep-original-filenames
+	InternalLinks          string        // -internal-links
+	AliasUnslashed         bool          // -alias-unslashed
+	AliasShortlink         bool          // -alias-shortlink
+	URLMap                 string        // -urlmap
+	Redirects              string        // -redirects
+	ContentField           string        // -content-field
+	Thumbnail              string        // -thumbnail
+	ThumbnailCrop          bool          // -thumbnail-crop
+	ThumbnailAll           bool          // -thumbnail-all
+	Strict                 bool          // -strict
+	FormatMap              string        // -format-map
+	FormatKey              string        // -format-key
+	CoverKey               string        // -cover-key
+	CoverFromFirstImage    bool          // -cover-from-first-image
+	ContentFormat          string        // -content-format
+	PrettifyHTML           bool          // -prettify-html
+	Checksums              string        // -checksums
+	ExcludeCategories      string        // -exclude-categories
+	IncludeCategories      string        // -include-categories
+	SkipExcludedItems      bool          // -skip-excluded-items
+	AllDrafts              bool          // -all-drafts
+	DraftCategory          string        // -draft-category
+	TableMode              string        // -table-mode
+	FlattenSingleItemLists bool          // -flatten-single-item-lists
+	EmojiSlug              string        // -emoji-slug
+	SkipTLSHosts           string        // -skip-tls-hosts
+	GlobalRate             float64       // -global-rate
+	UserAgent              string        // -user-agent
+	Overwrite              bool          // -overwrite
+	OutputBOM              bool          // -output-bom
+	DateSource             string        // -date-source
+	DateFormat             string        // -date-format
+	UTC                    bool          // -utc
+	OutputIndex            string        // -output-index
+	IndexGroup             string        // -index-group
+	StripAttrs             string        // -strip-attrs
+	FlattenImages          bool          // -flatten-images
+	TrimUTM                bool          // -trim-utm
+	StripLinkParams        string        // -strip-link-params
+	StripMediaParams       string        // -strip-media-params
+	SectionByCategory      string        // -section-by-category
+	CategoryHierarchy      string        // -category-hierarchy
+	TitleCase              string        // -title-case
+	FeedTimeout            time.Duration // -feed-timeout
+	DownloadTimeout        time.Duration // -download-timeout
+	LogLevel               string        // -log-level
+	LogJSON                bool          // -log-json
+	FrontMatterTemplate    string        // -frontmatter-template
+	Headers                []string      // -header, "Name: Value" each
+	AliasTemplates         []string      // -alias-template
+}
+
+// DefaultOptions returns the options with every flag at its default.
+func DefaultOptions() Options {
+	var o Options
+	o.RegisterFlags(flag.NewFlagSet("", flag.ContinueOnError))
+	return o
+}
+
+// RegisterFlags defines the command-line flags on fs, bound to the fields of
+// o, and sets the fields to their defaults.
+func (o *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Feed, "feed", "https://blog.breyer.berlin/feed/", "RSS feed URL or file path (site URL or /wp-json/wp/v2/posts with -source wp-rest); comma-separated to merge several")
+	fs.StringVar(&o.FeedUser, "feed-user", "", "User name for HTTP basic auth on the feed requests")
+	fs.StringVar(&o.FeedPass, "feed-pass", "", "Password for HTTP basic auth on the feed requests (with -feed-user)")
+	fs.BoolVar(&o.Paginate, "paginate", false, "Follow WordPress feed pages (?paged=2, 3, …) until one is empty or missing, to get more than the latest posts")
+	fs.IntVar(&o.MaxPages, "max-pages", 100, "Stop -paginate after this many pages per feed")
+	fs.StringVar(&o.Source, "source", "rss", "Where posts come from: rss (feed or WXR export) or wp-rest (WordPress REST API)")
+	fs.StringVar(&o.Out, "out", "content/posts", "Output directory for Hugo Markdown files")
+	fs.StringVar(&o.Static, "static", "static", "Hugo static directory (root of images/galleries)")
+	fs.StringVar(&o.Timezone, "tz", "Europe/Berlin", "IANA timezone for front matter dates, e.g. Europe/Berlin")
+	fs.IntVar(&o.Limit, "limit", 1, "Process only the first N items (0 = all)")
+	fs.IntVar(&o.Concurrency, "concurrency", 6, "Concurrent image download workers")
+	fs.IntVar(&o.Timeout, "timeout", 120, "Per-request download timeout in seconds (at least 10; see -download-timeout)")
+	fs.IntVar(&o.Retries, "retries", 3, "Number of download retries on failure")
+	fs.IntVar(&o.PerHost, "perhost", 4, "Max concurrent downloads per host")
+	fs.Float64Var(&o.Rate, "rate", 0, "Max media downloads started per second (0 = unlimited), on top of -concurrency and -perhost")
+	fs.DurationVar(&o.Progress, "progress", 10*time.Second, "Log how many of the scheduled media downloads are done at this interval while they run (0 = never)")
+	fs.DurationVar(&o.Delay, "delay", 0, "Pause after each media download before the worker starts the next one, e.g. 500ms (0 = none), to go easy on the origin")
+	fs.BoolVar(&o.RespectRobots, "respect-robots", false, "Fetch each image host's robots.txt once and keep the remote URL of images it disallows for the -user-agent")
+	fs.BoolVar(&o.Verbose, "v", true, "Verbose output (debug logs; false: info and up), unless -log-level is set")
+	fs.BoolVar(&o.Clean, "clean", false, "Before the run, delete the posts and media an earlier run wrote (listed in its -report manifest)")
+	fs.BoolVar(&o.Force, "force", false, "Let -clean delete the whole output folders even if they hold files no earlier run wrote")
+	fs.BoolVar(&o.Yes, "yes", false, "Clean output folders without asking (required for -clean when stdin is not a terminal)")
+	fs.StringVar(&o.TagsKey, "tags-key", "tags", "Front matter key for tags (dotted for nesting, e.g. params.topics)")
+	fs.StringVar(&o.CategoriesKey, "categories-key", "categories", "Front matter key for categories (dotted for nesting, e.g. params.sections)")
+	fs.StringVar(&o.SourceNames, "source-name", "", "Comma-separated names for the -feed sources, in order, written as a taxonomy term under -source-key to tell merged blogs apart")
+	fs.StringVar(&o.SourceKey, "source-key", "source", "Front matter key for the -source-name term (dotted for nesting)")
+	fs.StringVar(&o.FeedState, "feed-state", "", "Remember the feed's ETag/Last-Modified in this file and stop early when it has not changed since the last run")
+	fs.StringVar(&o.Report, "report", "", "Write a JSON manifest of processed items and their media to this path")
+	fs.IntVar(&o.SummaryLength, "summary-length", 160, "Max characters of the description: front matter, from the excerpt or the first paragraph (0 = none)")
+	fs.BoolVar(&o.ReadingTime, "reading-time", false, "Write readingTime (minutes at 200 words per minute) and wordCount of the body to the front matter")
+	fs.BoolVar(&o.ReadingTimeSkipCode, "reading-time-skip-code", false, "Leave fenced code blocks out of the -reading-time word count")
+	fs.BoolVar(&o.Canonical, "canonical", false, "Record the original post URL as canonicalURL in the front matter")
+	fs.BoolVar(&o.Bundles, "bundles", false, "Write Hugo leaf bundles: <out>/<slug>/index.md with the post's images and videos in the same folder, linked relatively")
+	fs.BoolVar(&o.SkipExisting, "skip-existing", true, "Reuse media files already on disk (non-empty) instead of downloading them again")
+	fs.StringVar(&o.CacheDir, "cache-dir", "", "Keep a copy of every downloaded media file in this folder and copy from there on later runs instead of downloading again (survives -clean)")
+	fs.BoolVar(&o.Resume, "resume", false, "Resume from the -report manifest: skip items whose markdown and media are complete")
+	fs.BoolVar(&o.GIFToMP4, "gif-to-mp4", false, "Transcode animated GIFs to looping MP4 videos (requires ffmpeg in PATH)")
+	fs.IntVar(&o.ContentMaxImages, "content-max-images", 0, "Keep only the first N images inline, the rest go into a {{< gallery >}} shortcode at the end (0 = no limit)")
+	fs.StringVar(&o.GalleryShortcode, "gallery-shortcode", "", "Template (or file) for galleries with [[ ]] delimiters over .Images (.Src .Alt .Caption), e.g. '{{< gallery >}}[[range .Images]]{{< img src=\"[[.Src]]\" >}}[[end]]{{< /gallery >}}'")
+	fs.BoolVar(&o.DedupeMedia, "dedupe-media", false, "Keep one copy of identical media files (same SHA-256, e.g. one image under several URLs) and point all posts at it")
+	fs.Int64Var(&o.MaxImageBytes, "max-image-bytes", 0, "Skip images larger than this many bytes and keep their remote URL (0 = no limit)")
+	fs.StringVar(&o.SlugFormat, "slug-format", "", "text/template for file names below -out with .Year .Month .Day .Slug, e.g. {{.Year}}/{{.Month}}/{{.Slug}} (default YYYY-MM-slug)")
+	fs.StringVar(&o.FrontMatterFormat, "frontmatter", "yaml", "Front matter format: yaml (---), toml (+++) or json")
+	fs.StringVar(&o.HugoConfig, "hugo-config", "", "Hugo site config (hugo.toml/yaml/json, or the site folder) to check permalinks, taxonomies and folders against before importing")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "Only log which files would be written and which media downloaded, touching nothing on disk")
+	fs.BoolVar(&o.Stdout, "stdout", false, "Write the posts (front matter and body) to stdout, separated by form feed lines, instead of -out; nothing is written to disk or downloaded")
+	fs.BoolVar(&o.Timing, "timing", false, "Print how long feed loading, each item and the downloads took, with the slowest items")
+	fs.BoolVar(&o.KeepOriginalFilenames, "keep-original-filenames", false, "Keep image file names as in the URL (no 001_ prefix) when safe and unique in the post's folder")
+	fs.StringVar(&o.InternalLinks, "internal-links", "keep", "Links between imported posts: keep (old URLs), ref ({{< ref >}} shortcodes to the new files) or path (new page paths)")
+	fs.BoolVar(&o.AliasUnslashed, "alias-unslashed", false, "Add each alias without its trailing slash too (/2024/03/title next to /2024/03/title/)")
+	fs.BoolVar(&o.AliasShortlink, "alias-shortlink", false, "Redirect WordPress' /?p=ID short link to the post in -redirects and -urlmap when the item's GUID is one (not a Hugo alias, which cannot match a query)")
+	fs.StringVar(&o.URLMap, "urlmap", "", "Write a CSV of old_url,new_url pairs (links and aliases) to this path")
+	fs.StringVar(&o.Redirects, "redirects", "", "Write 301 redirects from the old post paths and aliases to the new pages: Apache if the file is named .htaccess, else Netlify _redirects")
+	fs.StringVar(&o.ContentField, "content-field", "auto", "Feed field used as the post body: auto (content, else description), content, description or longest")
+	fs.StringVar(&o.Thumbnail, "thumbnail", "", "Generate a WIDTHxHEIGHT thumbnail of the cover (first) image and set thumbnail: in front matter")
+	fs.BoolVar(&o.ThumbnailCrop, "thumbnail-crop", true, "Crop thumbnails to exactly WIDTHxHEIGHT (false: fit inside, keeping the aspect ratio)")
+	fs.BoolVar(&o.ThumbnailAll, "thumbnail-all", false, "Generate thumbnails for every image, not just the cover")
+	fs.BoolVar(&o.Strict, "strict", false, "Fail an item instead of warning when its alias is already used by another post")
+	fs.StringVar(&o.FormatMap, "format-map", "", "Emit the WordPress post format as front matter, e.g. gallery=gallery,aside=note ('*' maps every format to its name)")
+	fs.StringVar(&o.FormatKey, "format-key", "kind", "Front matter key for the mapped post format (e.g. kind or type)")
+	fs.StringVar(&o.CoverKey, "cover-key", "cover", "Front matter key for the featured image (e.g. featured_image or params.cover)")
+	fs.BoolVar(&o.CoverFromFirstImage, "cover-from-first-image", false, "Use the post's first image as the cover when the feed names no featured image")
+	fs.StringVar(&o.ContentFormat, "content-format", "md", "Post body format: md (Markdown) or html (localized HTML in .html content files)")
+	fs.BoolVar(&o.PrettifyHTML, "prettify-html", false, "Normalize the post HTML (fix nesting, wrap loose text in paragraphs) before conversion")
+	fs.StringVar(&o.Checksums, "checksums", "", "Write a SHA256SUMS file of all downloaded media to this path (e.g. static/SHA256SUMS)")
+	fs.StringVar(&o.ExcludeCategories, "exclude-categories", "Allgemein", "Comma-separated categories (case-insensitive) left out of the front matter")
+	fs.StringVar(&o.IncludeCategories, "include-categories", "", "Comma-separated categories to keep; all others are left out (empty = keep all)")
+	fs.BoolVar(&o.SkipExcludedItems, "skip-excluded-items", false, "Skip posts whose categories are all left out by -exclude-categories/-include-categories")
+	fs.BoolVar(&o.AllDrafts, "all-drafts", false, "Mark every imported post as draft (e.g. for a trial migration)")
+	fs.StringVar(&o.DraftCategory, "draft-category", "", "Posts in this category (case-insensitive) become drafts; the category itself is not emitted")
+	fs.StringVar(&o.TableMode, "table-mode", "gfm", "HTML tables: gfm (pipe tables, raw HTML when too complex) or raw (keep the <table> HTML)")
+	fs.BoolVar(&o.FlattenSingleItemLists, "flatten-single-item-lists", false, "Render lists with a single item as a plain paragraph")
+	fs.StringVar(&o.EmojiSlug, "emoji-slug", "code", "Emoji in slugs: code (u1f389), name (party) or drop")
+	fs.StringVar(&o.SkipTLSHosts, "skip-tls-hosts", "", "Comma-separated hosts whose TLS certificates are not verified (all others are)")
+	fs.Float64Var(&o.GlobalRate, "global-rate", 0, "Max outbound HTTP requests per second across feeds and downloads (0 = unlimited)")
+	fs.StringVar(&o.UserAgent, "user-agent", "wordpress2hugo/1.0 (+https://example.com)", "User-Agent for the feed, API and media requests (some CDNs and WAFs block unknown agents)")
+	fs.BoolVar(&o.Overwrite, "overwrite", true, "Replace existing post files (false: keep them, e.g. after editing the Markdown by hand, and only add new posts)")
+	fs.BoolVar(&o.OutputBOM, "output-bom", false, "Start written Markdown files with a UTF-8 BOM (for Windows tools that need it)")
+	fs.StringVar(&o.DateSource, "date-source", "pubdate", "Post date source: pubdate, or content-time (first <time datetime> in the body, falling back to pubDate)")
+	fs.StringVar(&o.DateFormat, "date-format", "rfc3339", "Front matter date format: rfc3339, dateonly (2006-01-02) or a Go time layout")
+	fs.BoolVar(&o.UTC, "utc", false, "Write front matter dates in UTC instead of the -tz offset")
+	fs.StringVar(&o.OutputIndex, "output-index", "", "Write a Markdown page listing all imported posts to this path")
+	fs.StringVar(&o.IndexGroup, "index-group", "year", "Group the -output-index listing by year or category")
+	fs.StringVar(&o.StripAttrs, "strip-attrs", "", "Comma-separated attributes to remove from all elements, '*' suffix for prefixes (e.g. class,style,id,data-*)")
+	fs.BoolVar(&o.FlattenImages, "flatten-images", false, "Save the media of all posts in one folder (static/media) instead of one per post; names used by another URL get a short hash prefix")
+	fs.BoolVar(&o.TrimUTM, "trim-utm", false, "Strip tracking parameters (see -strip-link-params) from the links in post bodies")
+	fs.StringVar(&o.StripLinkParams, "strip-link-params", "utm_*,fbclid,gclid", "Comma-separated query parameters -trim-utm drops from links, '*' suffix for prefixes, e.g. utm_*,fbclid,gclid,mc_*,phpsessid")
+	fs.StringVar(&o.StripMediaParams, "strip-media-params", "utm_*,fbclid,gclid,ver", "Comma-separated query parameters dropped from image URLs before download, '*' suffix for prefixes (empty = keep all)")
+	fs.StringVar(&o.SectionByCategory, "section-by-category", "", "Write the posts of a category to another content section next to -out, e.g. Tutorial=tutorials,News=news (first matching pair wins)")
+	fs.StringVar(&o.CategoryHierarchy, "category-hierarchy", "flat", "Nested WordPress categories: flat (leaf name), path (parent/child term) or section (content sub-directories)")
+	fs.StringVar(&o.TitleCase, "title-case", "none", "Title case for the front matter title: none, title (Every Word) or sentence (first word only); entities are decoded in any case")
+	fs.DurationVar(&o.FeedTimeout, "feed-timeout", 30*time.Second, "Timeout of each feed, REST API and robots.txt request, e.g. 90s")
+	fs.DurationVar(&o.DownloadTimeout, "download-timeout", 0, "Timeout of each media download attempt, e.g. 5m for large originals or 15s for a flaky CDN (0 = -timeout)")
+	fs.StringVar(&o.LogLevel, "log-level", "", "Least important messages logged: error, warn, info or debug (default debug with -v, else info)")
+	fs.BoolVar(&o.LogJSON, "log-json", false, "Log one JSON object per line (time, level, msg) for machine parsing")
+	fs.StringVar(&o.FrontMatterTemplate, "frontmatter-template", "", "text/template (or file) producing extra YAML front matter per item, e.g. 'weight: {{sub 4102444800 .Date.Unix}}'")
+	fs.Var((*stringList)(&o.Headers), "header", `Extra HTTP header "Name: Value" for the feed and media requests (repeatable)`)
+	fs.Var((*stringList)(&o.AliasTemplates), "alias-template", "Extra alias as a text/template with .Year .Month .Day .Slug .Name .Path, e.g. /blog/{{.Name}}/ (repeatable)")
+}
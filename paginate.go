@@ -0,0 +1,66 @@
+package wordpress2hugo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// errFeedNotFound is a feed URL answering 404, which ends -paginate.
+var errFeedNotFound = errors.New("HTTP 404")
+
+// loadRSSPages loads src with loadRSS and, with -paginate, the pages after it
+// (?paged=2, 3, …), until a page is missing, has no items or only repeats
+// earlier ones, or -max-pages is reached. Items are merged in page order and
+// an item on several pages (posts shifting while paging) is kept once.
+func (c *Converter) loadRSSPages(src string) (*RSS, error) {
+	first, err := c.loadRSS(src)
+	if err != nil || !c.opts.Paginate || absHTTPURL(src) == nil {
+		return first, err
+	}
+	seen := map[string]bool{}
+	for _, item := range first.Channel.Items {
+		seen[itemID(item)] = true
+	}
+	for page := 2; page <= c.opts.MaxPages; page++ {
+		pageURL := feedPageURL(src, page)
+		rss, err := c.loadRSS(pageURL)
+		if errors.Is(err, ErrNotModified) {
+			// the first page changed, so this one is needed in full
+			c.feedCache.forget(pageURL)
+			rss, err = c.loadRSS(pageURL)
+		}
+		if errors.Is(err, errFeedNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		added := 0
+		for _, item := range rss.Channel.Items {
+			if id := itemID(item); !seen[id] {
+				seen[id] = true
+				first.Channel.Items = append(first.Channel.Items, item)
+				added++
+			}
+		}
+		c.debugf("feed: page %d, %d new items", page, added)
+		if added == 0 {
+			break
+		}
+	}
+	return first, nil
+}
+
+// feedPageURL adds WordPress' paged=n to a feed URL.
+func feedPageURL(src string, n int) string {
+	u, err := url.Parse(src)
+	if err != nil {
+		return src
+	}
+	q := u.Query()
+	q.Set("paged", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
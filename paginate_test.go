@@ -0,0 +1,92 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoadRSSPages(t *testing.T) {
+	setFlag(t, "v", "false")
+	setFlag(t, "paginate", "true")
+	item := func(n int) string {
+		return fmt.Sprintf(`<item><title>Post %d</title><link>https://example.com/%d/</link><guid>g%d</guid></item>`, n, n, n)
+	}
+	// page 2 repeats the last post of page 1, as when a post is published while paging
+	pages := map[string][]string{
+		"":  {item(1), item(2)},
+		"2": {item(2), item(3), item(4)},
+		"3": {item(5)},
+	}
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("paged")
+		mu.Lock()
+		requests = append(requests, page)
+		mu.Unlock()
+		if r.URL.Path == "/same/" {
+			page = "" // a server ignoring paged
+		}
+		items, ok := pages[page]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Blog</title>`+strings.Join(items, "")+`</channel></rss>`)
+	}))
+	defer srv.Close()
+
+	titles := func(rss *RSS) string {
+		var out []string
+		for _, it := range rss.Channel.Items {
+			out = append(out, it.Title)
+		}
+		return strings.Join(out, "|")
+	}
+	tests := []struct {
+		name, path, maxPages, want, requests string
+	}{
+		{"until 404", "/feed/", "100", "Post 1|Post 2|Post 3|Post 4|Post 5", ",2,3,4"},
+		{"max pages", "/feed/", "2", "Post 1|Post 2|Post 3|Post 4", ",2"},
+		{"paged ignored", "/same/", "100", "Post 1|Post 2", ",2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, "max-pages", tt.maxPages)
+			requests = nil
+			rss, err := conv.loadRSSPages(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(rss); got != tt.want {
+				t.Errorf("items = %s, want %s", got, tt.want)
+			}
+			if got := strings.Join(requests, ","); got != tt.requests {
+				t.Errorf("requested pages %q, want %q", got, tt.requests)
+			}
+		})
+	}
+
+	setFlag(t, "paginate", "false")
+	requests = nil
+	if rss, err := conv.loadRSSPages(srv.URL + "/feed/"); err != nil || titles(rss) != "Post 1|Post 2" || len(requests) != 1 {
+		t.Errorf("without -paginate: %v, %d requests", err, len(requests))
+	}
+}
+
+func TestFeedPageURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/feed/", "https://example.com/feed/?paged=3"},
+		{"https://example.com/?feed=rss2", "https://example.com/?feed=rss2&paged=3"},
+		{"https://example.com/feed/?paged=1", "https://example.com/feed/?paged=3"},
+	}
+	for _, tt := range tests {
+		if got := feedPageURL(tt.in, 3); got != tt.want {
+			t.Errorf("feedPageURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
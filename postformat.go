@@ -0,0 +1,48 @@
+package wordpress2hugo
+
+import "strings"
+
+func parseFormatMap(s string) map[string]string {
+	m := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok {
+			to = from
+		}
+		m[strings.ToLower(strings.TrimSpace(from))] = strings.TrimSpace(to)
+	}
+	return m
+}
+
+// postFormat returns the item's post format ("gallery"), or "" for standard
+// posts. WordPress records it as a category in the post_format domain.
+func postFormat(cats []Category) string {
+	for _, c := range cats {
+		if c.Domain != "post_format" {
+			continue
+		}
+		if f, ok := strings.CutPrefix(c.Nicename, "post-format-"); ok {
+			return f
+		}
+		return strings.ToLower(strings.TrimSpace(c.Value))
+	}
+	return ""
+}
+
+// formatKind is the front matter value for a post format, "" to omit it.
+func (c *Converter) formatKind(format string) string {
+	if format == "" {
+		return ""
+	}
+	if v, ok := c.formatMap[format]; ok {
+		return v
+	}
+	if _, ok := c.formatMap["*"]; ok {
+		return format
+	}
+	return ""
+}
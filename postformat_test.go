@@ -0,0 +1,55 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostFormatKind(t *testing.T) {
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/"><channel>` +
+		`<item><title>Fotos</title><link>https://example.com/2024/03/05/fotos/</link><guid>g1</guid>` +
+		`<category domain="category" nicename="reisen"><![CDATA[Reisen]]></category>` +
+		`<category domain="post_format" nicename="post-format-gallery"><![CDATA[Gallery]]></category>` +
+		`<description>Body</description></item></channel></rss>`
+	tests := []struct {
+		formatMap string
+		want      string
+	}{
+		{"", "categories:\n    - Reisen\n---\n"},
+		{"gallery=gallery", "categories:\n    - Reisen\nkind: gallery\n---\n"},
+		{"*", "categories:\n    - Reisen\nkind: gallery\n---\n"},
+		{"aside=note", "categories:\n    - Reisen\n---\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.formatMap, func(t *testing.T) {
+			dir := t.TempDir()
+			setFlag(t, "out", dir)
+			setFlag(t, "v", "false")
+			old := conv.formatMap
+			conv.formatMap = parseFormatMap(tt.formatMap)
+			t.Cleanup(func() { conv.formatMap = old })
+			feedPath := filepath.Join(dir, "feed.xml")
+			if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			rss, err := conv.loadRSS(feedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := conv.processItem(rss.Channel.Items[0], time.UTC, conv.newDownloader(1, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(rec.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("got\n%s\nwant it to contain\n%s", data, tt.want)
+			}
+		})
+	}
+}
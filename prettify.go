@@ -0,0 +1,91 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// prettifyHTML (-prettify-html) parses the post body and renders it back as
+// well-formed HTML: the parser closes and re-nests broken markup, and runs of
+// loose top-level text and inline elements are wrapped in a <p>, so the
+// block-by-block conversion does not tear a sentence apart. Comments are kept.
+func prettifyHTML(src string) (string, error) {
+	ctx := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := html.ParseFragment(strings.NewReader(src), ctx)
+	if err != nil {
+		return "", err
+	}
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	wrapInlineRuns(body)
+
+	var buf bytes.Buffer
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return "", err
+		}
+		if c.Type == html.ElementNode {
+			buf.WriteByte('\n')
+		}
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// wrapInlineRuns wraps consecutive inline children of parent in paragraphs.
+// Runs of whitespace and comments only are left alone.
+func wrapInlineRuns(parent *html.Node) {
+	var run []*html.Node
+	flush := func(before *html.Node) {
+		content := false
+		for _, n := range run {
+			if n.Type == html.ElementNode || (n.Type == html.TextNode && strings.TrimSpace(n.Data) != "") {
+				content = true
+			}
+		}
+		if content {
+			p := &html.Node{Type: html.ElementNode, DataAtom: atom.P, Data: "p"}
+			parent.InsertBefore(p, before)
+			for _, n := range run {
+				parent.RemoveChild(n)
+				p.AppendChild(n)
+			}
+		}
+		run = nil
+	}
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+		if isInlineNode(c) {
+			run = append(run, c)
+		} else {
+			flush(c)
+		}
+		c = next
+	}
+	flush(nil)
+}
+
+var blockAtoms = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Details: true,
+	atom.Div: true, atom.Dl: true, atom.Fieldset: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.Iframe: true, atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Section: true, atom.Table: true, atom.Ul: true, atom.Video: true, atom.Audio: true, atom.Script: true,
+	atom.Style: true, atom.Noscript: true,
+}
+
+// isInlineNode reports whether n belongs inside a paragraph. Comments count as
+// block-level so <!--more--> stays between paragraphs.
+func isInlineNode(n *html.Node) bool {
+	switch n.Type {
+	case html.TextNode:
+		return true
+	case html.ElementNode:
+		return !blockAtoms[n.DataAtom]
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestPrettifyHTML(t *testing.T) {
+	in := `Loose <em>intro</em> text<p>First<p>Second <b>bold<i>mixed</b> end</i><div>Block</div>trailing`
+	wantHTML := "<p>Loose <em>intro</em> text</p>\n<p>First</p>\n<p>Second <b>bold<i>mixed</i></b><i> end</i></p>\n<div>Block</div>\n<p>trailing</p>"
+	got, err := prettifyHTML(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != wantHTML {
+		t.Errorf("html: got\n%q\nwant\n%q", got, wantHTML)
+	}
+
+	md, err := conv.toMarkdownPreserveOrder(got, "slug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMD := "Loose intro text\n\nFirst\n\nSecond boldmixed end\n\nBlock\n\ntrailing"
+	if md != wantMD {
+		t.Errorf("markdown: got\n%q\nwant\n%q", md, wantMD)
+	}
+}
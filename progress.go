@@ -0,0 +1,61 @@
+package wordpress2hugo
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dlProgress counts the downloader's work for -progress and the summary.
+type dlProgress struct {
+	scheduled atomic.Int64 // URLs taken on (not dry-run)
+	completed atomic.Int64 // finished, successfully or not
+	succeeded atomic.Int64 // finished without error, reused files included
+	reused    atomic.Int64 // files from an earlier run (-skip-existing)
+}
+
+// reportProgress logs "downloads: completed/scheduled" every interval while
+// the count changes, and a last time when stop is called. A zero interval
+// logs nothing.
+func (d *downloader) reportProgress(every time.Duration) (stop func()) {
+	if every <= 0 {
+		return func() {}
+	}
+	quit, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(every)
+		defer t.Stop()
+		last := int64(-1)
+		report := func() {
+			done, total := d.progress.completed.Load(), d.progress.scheduled.Load()
+			if total > 0 && done != last {
+				d.c.infof("downloads: %d/%d", done, total)
+				last = done
+			}
+		}
+		for {
+			select {
+			case <-quit:
+				report()
+				return
+			case <-t.C:
+				report()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-exited
+	}
+}
+
+// logSummary logs the outcome of a run: how long it took, the posts written
+// (and items that failed) and the media downloaded (and failed).
+func (c *Converter) logSummary(started time.Time, written, failedItems int, d *downloader) {
+	p := &d.progress
+	downloaded := p.succeeded.Load() - p.reused.Load()
+	c.infof("done in %v: %d posts written, %d items failed; %d media files downloaded, %d already on disk, %d failed",
+		time.Since(started).Round(time.Millisecond), written, failedItems, downloaded, p.reused.Load(), len(d.Failures()))
+}
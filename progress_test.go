@@ -0,0 +1,83 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloaderProgress(t *testing.T) {
+	setFlag(t, "v", "false")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(30 * time.Millisecond)
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "old.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dl := conv.newDownloader(1, 1)
+	stop := dl.reportProgress(10 * time.Millisecond)
+	for _, name := range []string{"a.png", "b.png", "missing.png", "old.png", "a.png"} {
+		dl.Schedule(srv.URL+"/"+name, filepath.Join(dir, name))
+	}
+	dl.Wait()
+	stop()
+
+	p := &dl.progress
+	if got := [4]int64{p.scheduled.Load(), p.completed.Load(), p.succeeded.Load(), p.reused.Load()}; got != [4]int64{4, 4, 3, 1} {
+		t.Errorf("scheduled, completed, succeeded, reused = %v, want [4 4 3 1]", got)
+	}
+	if !strings.Contains(logs.String(), "downloads: 4/4\n") {
+		t.Errorf("no final progress line:\n%s", logs.String())
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link>` +
+		`<content:encoded><![CDATA[<p><img src="` + srv.URL + `/a.jpg"><img src="` + srv.URL + `/b.png"></p>]]></content:encoded></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link><pubDate>someday</pubDate><description>b</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(dir, "posts"), "-static", filepath.Join(dir, "static"),
+		"-limit", "0", "-v=false")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"1/2 ✓ First -> 2024-02-first.md",
+		"2/2 ✓ Second -> 2024-01-second.md",
+		"warn: 2/2 pubDate parse failed", // warnings name their item too
+		": 2 posts written, 0 items failed; 2 media files downloaded, 0 already on disk, 0 failed\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
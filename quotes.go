@@ -0,0 +1,73 @@
+package wordpress2hugo
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// quoteMarkdown renders a <blockquote> as "> " lines: a paragraph per block
+// child, nested blockquotes quoted once more, and the <cite> (Gutenberg quote
+// blocks, <footer><cite>) as an em-dashed attribution line at the end.
+func quoteMarkdown(q *goquery.Selection) string {
+	var paras []string
+	var cite string
+	var inline strings.Builder
+	flush := func() {
+		if t := strings.Join(strings.Fields(inline.String()), " "); t != "" {
+			paras = append(paras, t)
+		}
+		inline.Reset()
+	}
+	q.Contents().Each(func(_ int, c *goquery.Selection) {
+		name := goquery.NodeName(c)
+		switch {
+		case name == "#comment":
+		case name == "cite":
+			cite = citeText(c)
+		case name == "br":
+			flush()
+		case name == "#text" || c.Is("a, abbr, b, code, em, i, mark, q, small, span, strong, sub, sup, time, u"):
+			inline.WriteString(c.Text())
+		case name == "blockquote":
+			flush()
+			if nested := quoteMarkdown(c); nested != "" {
+				paras = append(paras, nested)
+			}
+		default:
+			flush()
+			block := c.Clone()
+			if cs := block.Find("cite"); cs.Length() > 0 {
+				cite = citeText(cs.Last())
+				cs.Remove()
+			}
+			t := strings.Join(strings.Fields(block.Text()), " ")
+			if t = strings.TrimRight(t, " —–-"); t != "" {
+				paras = append(paras, t)
+			}
+		}
+	})
+	flush()
+
+	var lines []string
+	for i, p := range paras {
+		if i > 0 {
+			lines = append(lines, ">")
+		}
+		for _, l := range strings.Split(p, "\n") {
+			lines = append(lines, "> "+l)
+		}
+	}
+	if cite != "" {
+		if len(lines) > 0 {
+			lines = append(lines, ">")
+		}
+		lines = append(lines, "> — "+cite)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// citeText is the attribution without the dash authors often type before it.
+func citeText(c *goquery.Selection) string {
+	return strings.TrimLeft(strings.Join(strings.Fields(c.Text()), " "), "—–- ")
+}
@@ -0,0 +1,33 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestBlockquotes(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"quote block with cite", `<blockquote class="wp-block-quote"><p>Less is more.</p><p>Really.</p><cite>Mies van der Rohe</cite></blockquote>`,
+			"> Less is more.\n>\n> Really.\n>\n> — Mies van der Rohe"},
+		{"footer cite", `<blockquote><p>Quote <strong>me</strong></p><footer>— <cite>Jane Doe</cite></footer></blockquote>`,
+			"> Quote me\n>\n> — Jane Doe"},
+		{"cite in the last paragraph", `<blockquote><p>Hello</p><p>&ndash; <cite>Bob</cite></p></blockquote>`,
+			"> Hello\n>\n> — Bob"},
+		{"no cite", `<blockquote>Plain <em>text</em><br>second line</blockquote>`,
+			"> Plain text\n>\n> second line"},
+		{"nested", `<blockquote><p>Outer</p><blockquote><p>Inner</p><cite>Inner Source</cite></blockquote><cite>Outer Source</cite></blockquote>`,
+			"> Outer\n>\n> > Inner\n> >\n> > — Inner Source\n>\n> — Outer Source"},
+		{"between paragraphs", `<p>Before</p><blockquote><p>Quoted</p></blockquote><p>After</p>`,
+			"Before\n\n> Quoted\n\nAfter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := conv.toMarkdownPreserveOrder(tt.in, "s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package wordpress2hugo
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a small token bucket: a ticker refills tokens at the
+// configured rate and every outbound request takes one. A nil limiter never blocks.
+type rateLimiter struct {
+	tokens   chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	burst := int(math.Ceil(perSecond))
+	l := &rateLimiter{tokens: make(chan struct{}, burst), stop: make(chan struct{})}
+	l.tokens <- struct{}{} // allow the first request immediately
+	interval := time.Duration(float64(time.Second) / perSecond)
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-t.C:
+			}
+			select {
+			case l.tokens <- struct{}{}:
+			default: // bucket full
+			}
+		}
+	}()
+	return l
+}
+
+// Stop ends the refills. Requests still waiting then wait for good, so call
+// it once no more are made.
+func (l *rateLimiter) Stop() {
+	if l != nil {
+		l.stopOnce.Do(func() { close(l.stop) })
+	}
+}
+
+func (l *rateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+	<-l.tokens
+}
+
+// maxRetryAfter caps how long a download waits on a 429's Retry-After.
+const maxRetryAfter = 5 * time.Minute
+
+// retryAfter parses a Retry-After header, either delay seconds or an HTTP
+// date, into how long to wait from now. Missing or invalid values give 0 (use
+// the normal backoff); long waits are capped at maxRetryAfter.
+func retryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	return min(d, maxRetryAfter)
+}
@@ -0,0 +1,173 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		rate  float64
+		waits int
+	}{
+		{5, 4},
+		{20, 8},
+		{0.5, 1}, // the first request never waits
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		l := newRateLimiter(tt.rate)
+		for i := 0; i < tt.waits; i++ {
+			l.Wait()
+		}
+		min := time.Duration(float64(tt.waits-1) / tt.rate * float64(time.Second))
+		if got := time.Since(start); got < min*9/10 {
+			t.Errorf("rate %v: %d waits took %v, want at least %v", tt.rate, tt.waits, got, min)
+		}
+		l.Stop()
+	}
+
+	// without -global-rate there is no limiter, and waiting on it is free
+	l := newRateLimiter(0)
+	if l != nil {
+		t.Fatalf("newRateLimiter(0) = %v, want nil", l)
+	}
+	l.Wait()
+	l.Stop()
+}
+
+func TestRateLimiterStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		l := newRateLimiter(100)
+		l.Stop()
+		l.Stop() // again, as a second Close would
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after stopping the limiters, %d before", n, before)
+	}
+}
+
+func TestDownloaderRate(t *testing.T) {
+	setFlag(t, "v", "false")
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	dl := conv.newDownloader(6, 6)
+	dl.limiter = newRateLimiter(10)
+	for i := 0; i < 5; i++ {
+		dl.Schedule(fmt.Sprintf("%s/%d.png", srv.URL, i), filepath.Join(dir, fmt.Sprintf("%d.png", i)))
+	}
+	dl.Wait()
+	if f := dl.Failures(); len(f) > 0 {
+		t.Fatalf("failures: %v", f)
+	}
+	if len(times) != 5 {
+		t.Fatalf("%d requests, want 5", len(times))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	if span := times[4].Sub(times[0]); span < 360*time.Millisecond {
+		t.Errorf("5 downloads at -rate 10 took %v, want at least 400ms", span)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"Fri, 15 Mar 2024 12:00:30 GMT", 30 * time.Second},
+		{"Fri, 15 Mar 2024 11:00:00 GMT", 0}, // in the past
+		{"-5", 0},
+		{"soon", 0},
+		{"86400", maxRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.in, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadRetriesTooManyRequests(t *testing.T) {
+	setFlag(t, "retries", "2")
+	var hits atomic.Int32
+	var first time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.png")
+	if _, _, err := conv.downloadFile(srv.URL+"/a.png", dest); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("%d requests, want 2", hits.Load())
+	}
+	if waited := time.Since(first); waited < 900*time.Millisecond || waited > 1900*time.Millisecond {
+		t.Errorf("retried after %v, want the Retry-After of 1s", waited)
+	}
+}
+
+func TestDownloaderDelay(t *testing.T) {
+	setFlag(t, "v", "false")
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	dl := conv.newDownloader(1, 1)
+	dl.delay = 150 * time.Millisecond
+	for i := 0; i < 3; i++ {
+		dl.Schedule(fmt.Sprintf("%s/%d.png", srv.URL, i), filepath.Join(dir, fmt.Sprintf("%d.png", i)))
+	}
+	dl.Wait()
+	if len(times) != 3 {
+		t.Fatalf("%d requests, want 3", len(times))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i := 1; i < 3; i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 150*time.Millisecond {
+			t.Errorf("download %d started %v after the previous one, want at least the 150ms -delay", i, gap)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package wordpress2hugo
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// wordsPerMinute is the reading speed behind readingTime.
+const wordsPerMinute = 200
+
+// wordCount counts the words of a post body: whitespace-separated runs with
+// at least one letter or digit, so Markdown markers (#, >, -) do not count.
+// With skipCode, fenced code blocks (<pre> in an HTML body) are left out.
+func wordCount(body string, isHTML, skipCode bool) int {
+	if isHTML {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+		if err != nil {
+			return 0
+		}
+		if skipCode {
+			doc.Find("pre").Remove()
+		}
+		// text nodes one by one, so adjacent blocks do not run into each other
+		n := 0
+		doc.Find("*").Contents().Each(func(_ int, c *goquery.Selection) {
+			if c.Get(0).Type == html.TextNode {
+				n += countWords(c.Text())
+			}
+		})
+		return n
+	}
+	if !skipCode {
+		return countWords(body)
+	}
+	var text strings.Builder
+	fence := ""
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+		case fence != "" && strings.HasPrefix(trimmed, fence):
+			fence = ""
+		case fence == "":
+			text.WriteString(line)
+			text.WriteByte('\n')
+		}
+	}
+	return countWords(text.String())
+}
+
+func countWords(s string) int {
+	n := 0
+	for _, w := range strings.Fields(s) {
+		if strings.IndexFunc(w, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// readingTime is the whole minutes it takes to read words, rounded up.
+func readingTime(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
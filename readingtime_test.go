@@ -0,0 +1,51 @@
+package wordpress2hugo
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWordCount(t *testing.T) {
+	md := "# Title here\n\nOne two three.\n\n> - quoted item\n\n```go\nfunc main() {\n\tprintln(\"hi\")\n}\n```\n\n~~~\nraw text\n~~~\nLast words"
+	tests := []struct {
+		name     string
+		body     string
+		html     bool
+		skipCode bool
+		want     int
+	}{
+		{"markdown", md, false, false, 15},
+		{"markdown without code", md, false, true, 9},
+		{"html", "<p>One <b>two</b> three</p><pre><code>x := 1</code></pre>", true, false, 5},
+		{"html without code", "<p>One <b>two</b> three</p><pre><code>x := 1</code></pre>", true, true, 3},
+		{"empty", "", false, false, 0},
+	}
+	for _, tt := range tests {
+		if got := wordCount(tt.body, tt.html, tt.skipCode); got != tt.want {
+			t.Errorf("%s: wordCount = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 1, 200: 1, 201: 2, 1000: 5} {
+		if got := readingTime(words); got != want {
+			t.Errorf("readingTime(%d) = %d, want %d", words, got, want)
+		}
+	}
+
+	fm := FrontMatter{Title: "Post", WordCount: 420, ReadingTime: 3}
+	data, err := yaml.Marshal(fm.toMap(conv.opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "readingTime: 3\nwordCount: 420\n") {
+		t.Errorf("front matter:\n%s", data)
+	}
+	fm.WordCount, fm.ReadingTime = 0, 0
+	if data, _ := yaml.Marshal(fm.toMap(conv.opts)); strings.Contains(string(data), "readingTime") {
+		t.Errorf("readingTime without -reading-time:\n%s", data)
+	}
+}
@@ -0,0 +1,69 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// writeRedirects writes a permanent redirect from each post's original path
+// and each of its aliases to its new page (see pagePath). A file named
+// .htaccess gets Apache Redirect lines, anything else Netlify's _redirects
+// format. Aliases with a query (/?p=ID) match on it: a query condition for
+// Netlify, RewriteCond/RewriteRule for Apache.
+func (c *Converter) writeRedirects(p string, recs []*postRecord) error {
+	apache := filepath.Base(p) == ".htaccess"
+	var buf bytes.Buffer
+	seen := map[string]bool{}
+	rewrites := false
+	for _, rec := range recs {
+		if rec.File == "" {
+			continue
+		}
+		newPath := c.pagePath(rec.File)
+		var olds []string
+		if u, err := url.Parse(rec.Link); err == nil && rec.Link != "" {
+			olds = append(olds, ensureTrailingSlash(u.Path))
+		}
+		olds = append(olds, rec.redirectPaths()...)
+		for _, old := range olds {
+			if old == newPath || old == "/" || seen[old] {
+				continue
+			}
+			seen[old] = true
+			from, to := escapePath(old), escapePath(newPath)
+			if oldPath, query, ok := strings.Cut(old, "?"); ok {
+				if !apache {
+					fmt.Fprintf(&buf, "%s %s %s 301\n", escapePath(oldPath), query, to)
+					continue
+				}
+				if !rewrites {
+					buf.WriteString("RewriteEngine On\n")
+					rewrites = true
+				}
+				fmt.Fprintf(&buf, "RewriteCond %%{QUERY_STRING} ^%s$\nRewriteRule ^%s$ %s? [R=301,L]\n",
+					regexp.QuoteMeta(query), regexp.QuoteMeta(strings.TrimPrefix(escapePath(oldPath), "/")), to)
+				continue
+			}
+			if apache {
+				fmt.Fprintf(&buf, "Redirect 301 %s %s\n", from, to)
+			} else {
+				fmt.Fprintf(&buf, "%s %s 301\n", from, to)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, buf.Bytes(), 0o644)
+}
+
+// escapePath percent-encodes spaces and other characters that would split a
+// redirect line.
+func escapePath(p string) string {
+	return (&url.URL{Path: p}).EscapedPath()
+}
@@ -0,0 +1,79 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedirects(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link><description>a</description></item>` +
+		`<item><title>Zweiter Beitrag</title><link>https://example.com/2024/01/15/zweiter beitrag/?ref=x</link><description>b</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ file, want string }{
+		{"_redirects", "/2024/02/01/first/ /posts/2024-02-first/ 301\n" +
+			"/blog/first/ /posts/2024-02-first/ 301\n" +
+			"/2024/01/15/zweiter%20beitrag/ /posts/2024-01-zweiter-beitrag/ 301\n" +
+			"/blog/zweiter-beitrag/ /posts/2024-01-zweiter-beitrag/ 301\n"},
+		{".htaccess", "Redirect 301 /2024/02/01/first/ /posts/2024-02-first/\n" +
+			"Redirect 301 /blog/first/ /posts/2024-02-first/\n" +
+			"Redirect 301 /2024/01/15/zweiter%20beitrag/ /posts/2024-01-zweiter-beitrag/\n" +
+			"Redirect 301 /blog/zweiter-beitrag/ /posts/2024-01-zweiter-beitrag/\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			p := filepath.Join(dir, "site", "static", tt.file)
+			log, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(dir, "site", "content", "posts"), "-static", filepath.Join(dir, "site", "static"),
+				"-limit", "0", "-redirects", p, "-alias-template", "/blog/{{.Name}}/")
+			if err != nil {
+				t.Fatalf("%v\n%s", err, log)
+			}
+			got, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortlinkRedirects(t *testing.T) {
+	dir := t.TempDir()
+	recs := []*postRecord{{
+		File:      filepath.Join(dir, "content", "posts", "2024-02-first.md"),
+		Link:      "https://example.com/2024/02/01/first/",
+		Aliases:   []string{"/2024/02/01/first/", "/2024/02/01/first"},
+		Shortlink: "/?p=42",
+	}}
+	tests := []struct{ file, want string }{
+		{"_redirects", "/2024/02/01/first/ /posts/2024-02-first/ 301\n" +
+			"/2024/02/01/first /posts/2024-02-first/ 301\n" +
+			"/ p=42 /posts/2024-02-first/ 301\n"},
+		{".htaccess", "Redirect 301 /2024/02/01/first/ /posts/2024-02-first/\n" +
+			"Redirect 301 /2024/02/01/first /posts/2024-02-first/\n" +
+			"RewriteEngine On\n" +
+			"RewriteCond %{QUERY_STRING} ^p=42$\n" +
+			"RewriteRule ^$ /posts/2024-02-first/? [R=301,L]\n"},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.file)
+		if err := conv.writeRedirects(p, recs); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package wordpress2hugo
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type robotsCache struct {
+	mu      sync.Mutex
+	origins map[string]*robotsOrigin
+	fetch   func(origin string) []robotsRule
+}
+
+type robotsOrigin struct {
+	once  sync.Once
+	rules []robotsRule
+}
+
+// robotsRule is an Allow or Disallow line of the group that applies to us.
+type robotsRule struct {
+	pattern string // path with * wildcards and an optional $ end anchor
+	allow   bool
+}
+
+func newRobotsCache(on bool, fetch func(origin string) []robotsRule) *robotsCache {
+	if !on {
+		return nil
+	}
+	return &robotsCache{origins: map[string]*robotsOrigin{}, fetch: fetch}
+}
+
+// allowed reports whether robots.txt lets us fetch rawURL.
+func (c *robotsCache) allowed(rawURL string) bool {
+	if c == nil {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return true
+	}
+	origin := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	o, ok := c.origins[origin]
+	if !ok {
+		o = &robotsOrigin{}
+		c.origins[origin] = o
+	}
+	c.mu.Unlock()
+	o.once.Do(func() { o.rules = c.fetch(origin) })
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	return robotsAllow(o.rules, p)
+}
+
+// fetchRobots loads the rules of origin's robots.txt for our -user-agent. A
+// missing or unreadable robots.txt allows everything.
+func (c *Converter) fetchRobots(origin string) []robotsRule {
+	req, err := http.NewRequest("GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	c.addRequestHeaders(req)
+	c.globalLimiter.Wait()
+	resp, err := c.newHTTPClient(c.opts.FeedTimeout).Do(req)
+	if err != nil {
+		c.warnf("robots.txt of %s: %v (allowing all)", origin, err)
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		c.debugf("robots.txt of %s: HTTP %d (allowing all)", origin, resp.StatusCode)
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		c.warnf("robots.txt of %s: %v (allowing all)", origin, err)
+		return nil
+	}
+	return parseRobots(data, robotsAgent(c.opts.UserAgent))
+}
+
+// robotsAgent is the product token of a User-Agent, e.g. wordpress2hugo for
+// "wordpress2hugo/1.0 (+https://example.com)".
+func robotsAgent(ua string) string {
+	ua, _, _ = strings.Cut(strings.TrimSpace(ua), " ")
+	ua, _, _ = strings.Cut(ua, "/")
+	return strings.ToLower(ua)
+}
+
+// parseRobots returns the rules of the groups naming agent, or of the "*"
+// groups when none does.
+func parseRobots(data []byte, agent string) []robotsRule {
+	var own, star []robotsRule
+	var ownGroup, starGroup, hasOwn, sawAgent bool
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !sawAgent { // a new group starts
+				ownGroup, starGroup = false, false
+			}
+			sawAgent = true
+			name := strings.ToLower(value)
+			if name == "*" {
+				starGroup = true
+			} else if agent != "" && strings.Contains(agent, name) {
+				ownGroup, hasOwn = true, true
+			}
+		case "allow", "disallow":
+			sawAgent = false
+			if value == "" {
+				continue // "Disallow:" allows everything
+			}
+			r := robotsRule{pattern: value, allow: key == "allow"}
+			if ownGroup {
+				own = append(own, r)
+			}
+			if starGroup {
+				star = append(star, r)
+			}
+		default:
+			sawAgent = false
+		}
+	}
+	if hasOwn {
+		return own
+	}
+	return star
+}
+
+// robotsAllow applies the longest matching rule to p; on a tie Allow wins.
+func robotsAllow(rules []robotsRule, p string) bool {
+	best, allow := -1, true
+	for _, r := range rules {
+		if !robotsMatch(r.pattern, p) {
+			continue
+		}
+		if n := len(r.pattern); n > best || (n == best && r.allow) {
+			best, allow = n, r.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches p against a robots.txt path pattern: a prefix with *
+// for any characters and a trailing $ for the end of the path.
+func robotsMatch(pattern, p string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(p, parts[0]) {
+		return false
+	}
+	rest := p[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
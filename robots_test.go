@@ -0,0 +1,80 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRobotsRules(t *testing.T) {
+	txt := `# robots.txt
+User-agent: *
+Disallow: /wp-content/uploads/private/
+Allow: /wp-content/uploads/private/public-*.jpg$
+Disallow: /*.gif$
+
+User-agent: BadBot
+User-agent: wordpress2hugo
+Disallow: /wp-content/
+Allow: /wp-content/uploads/
+`
+	tests := []struct {
+		agent, path string
+		want        bool
+	}{
+		{"other", "/wp-content/uploads/a.jpg", true},
+		{"other", "/wp-content/uploads/private/a.jpg", false},
+		{"other", "/wp-content/uploads/private/public-a.jpg", true},
+		{"other", "/wp-content/uploads/private/public-a.jpg?ver=2", false},
+		{"other", "/2024/anim.gif", false},
+		{"other", "/2024/anim.gif.jpg", true},
+		{"wordpress2hugo", "/wp-content/themes/x.png", false},
+		{"wordpress2hugo", "/wp-content/uploads/private/a.jpg", true}, // only its own group applies
+		{"wordpress2hugo", "/2024/anim.gif", true},
+	}
+	for _, tt := range tests {
+		if got := robotsAllow(parseRobots([]byte(txt), tt.agent), tt.path); got != tt.want {
+			t.Errorf("%s %s: allowed = %v, want %v", tt.agent, tt.path, got, tt.want)
+		}
+	}
+	if got := robotsAgent("wordpress2hugo/1.0 (+https://example.com)"); got != "wordpress2hugo" {
+		t.Errorf("robotsAgent = %q", got)
+	}
+}
+
+func TestRespectRobots(t *testing.T) {
+	var robotsHits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsHits.Add(1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	setFlag(t, "v", "false")
+	setFlag(t, "static", t.TempDir())
+	conv.robots = newRobotsCache(true, conv.fetchRobots)
+	defer func() { conv.robots = nil }()
+
+	dl := conv.newDownloader(2, 2)
+	in := `<p><img src="` + srv.URL + `/private/a.jpg"/><img src="` + srv.URL + `/public/b.jpg"/><img src="` + srv.URL + `/private/c.jpg"/></p>`
+	out, err := conv.rewriteAndDownloadImages(in, nil, "slug", dl, &postRecord{})
+	dl.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{srv.URL + "/private/a.jpg", "/media/slug/002_b.jpg", srv.URL + "/private/c.jpg"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if n := robotsHits.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", n)
+	}
+}
@@ -0,0 +1,96 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// sectionRoute sends the posts of a category to a content section.
+type sectionRoute struct {
+	category string
+	dir      string // slash-separated, relative to the parent of -out
+}
+
+// parseSectionRoutes parses Category=section pairs, e.g.
+// "Tutorial=tutorials,News=news".
+func parseSectionRoutes(s string) ([]sectionRoute, error) {
+	var routes []sectionRoute
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		cat, dir, _ := strings.Cut(pair, "=")
+		cat, dir = strings.TrimSpace(cat), path.Clean(filepath.ToSlash(strings.TrimSpace(dir)))
+		if cat == "" || dir == "." || path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("want Category=section with a section below the content folder, got %q", pair)
+		}
+		routes = append(routes, sectionRoute{category: cat, dir: dir})
+	}
+	return routes, nil
+}
+
+// sectionRouteFor returns the first -section-by-category pair whose category
+// is among cats, the item's categories after -exclude-categories and
+// -draft-category, or nil to keep the post in -out.
+func (c *Converter) sectionRouteFor(cats []string) *sectionRoute {
+	for i, r := range c.sectionRoutes {
+		for _, cat := range cats {
+			if strings.EqualFold(cat, r.category) {
+				return &c.sectionRoutes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ensureRouteIndexes writes an _index.md for every level of a nested route
+// below the first (News=blog/news → content/blog/news/_index.md): Hugo makes
+// top-level folders of content sections on its own, deeper ones only with
+// an index. The deepest level is titled after the category. It returns the
+// indexes that are the tool's, like ensureSectionIndexes.
+func (c *Converter) ensureRouteIndexes(contentDir string, r sectionRoute) ([]string, error) {
+	var indexes []string
+	parts := strings.Split(r.dir, "/")
+	for i := 1; i < len(parts); i++ {
+		title := parts[i]
+		if i == len(parts)-1 {
+			title = r.category
+		}
+		idx := filepath.Join(contentDir, filepath.FromSlash(path.Join(parts[:i+1]...)), "_index.md")
+		own, err := c.writeSectionIndex(idx, title)
+		if err != nil {
+			return nil, err
+		}
+		if own {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes, nil
+}
+
+// routedDirs returns the -section-by-category folders next to contentOut that
+// -clean walks besides it, leaving out those inside contentOut or inside
+// another route's folder, which that walk covers already.
+func (c *Converter) routedDirs(contentOut string) []string {
+	within := func(p, dir string) bool {
+		rel, err := filepath.Rel(dir, p)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+	var dirs []string
+	for _, r := range c.sectionRoutes {
+		dir := filepath.Join(contentOut, "..", filepath.FromSlash(r.dir))
+		covered := within(dir, contentOut)
+		for _, other := range c.sectionRoutes {
+			if other.dir != r.dir && within(dir, filepath.Join(contentOut, "..", filepath.FromSlash(other.dir))) {
+				covered = true
+			}
+		}
+		if !covered && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,101 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSectionRoutes(t *testing.T) {
+	routes, err := parseSectionRoutes("Tutorial=tutorials, News = docs/news/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0] != (sectionRoute{"Tutorial", "tutorials"}) || routes[1] != (sectionRoute{"News", "docs/news"}) {
+		t.Errorf("routes = %+v", routes)
+	}
+	for _, bad := range []string{"Tutorial", "=news", "News=../news", "News=/abs"} {
+		if _, err := parseSectionRoutes(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestSectionByCategory(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel>` +
+		`<item><title>Howto</title><link>https://example.com/2024/02/01/howto/</link><category>Tutorial</category><description>a</description></item>` +
+		`<item><title>Update</title><link>https://example.com/2024/01/15/update/</link><category>news</category><category>Tutorial</category><description>b</description></item>` +
+		`<item><title>Other</title><link>https://example.com/2024/01/10/other/</link><category>Misc</category><description>c</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	content := filepath.Join(dir, "site", "content")
+	urlmap := filepath.Join(dir, "urlmap.csv")
+	log, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(content, "posts"), "-static", filepath.Join(dir, "site", "static"),
+		"-limit", "0", "-section-by-category", "News=news,Tutorial=tutorials", "-urlmap", urlmap)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	for _, want := range []string{
+		filepath.Join(content, "tutorials", "2024-02-howto.md"),
+		filepath.Join(content, "news", "2024-01-update.md"), // the first pair wins
+		filepath.Join(content, "posts", "2024-01-other.md"),
+	} {
+		if !fileExists(want) {
+			t.Errorf("%s missing", want)
+		}
+	}
+	data, err := os.ReadFile(urlmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "https://example.com/2024/02/01/howto/,/tutorials/2024-02-howto/\n") {
+		t.Errorf("url map:\n%s", data)
+	}
+}
+
+func TestSectionByCategoryNestedClean(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	writeFeed := func(items string) {
+		feed := `<?xml version="1.0"?><rss version="2.0"><channel>` + items + `</channel></rss>`
+		if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	update := `<item><title>Update</title><link>https://example.com/2024/01/15/update/</link><category>News</category><description>b</description></item>`
+	howto := `<item><title>Howto</title><link>https://example.com/2024/02/01/howto/</link><category>Tutorial</category><category>Misc</category><description>a</description></item>`
+	writeFeed(update + howto)
+	content := filepath.Join(dir, "site", "content")
+	args := []string{"-feed", feedPath, "-out", filepath.Join(content, "posts"), "-static", filepath.Join(dir, "site", "static"),
+		"-limit", "0", "-section-by-category", "Tutorial=tutorials,News=blog/news", "-exclude-categories", "Tutorial",
+		"-report", filepath.Join(dir, "report.json")}
+	if log, err := runMain(t, args...); err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	news := filepath.Join(content, "blog", "news", "2024-01-update.md")
+	index := filepath.Join(content, "blog", "news", "_index.md")
+	for _, want := range []string{news, index, filepath.Join(content, "posts", "2024-02-howto.md")} {
+		if !fileExists(want) {
+			t.Errorf("%s missing", want)
+		}
+	}
+	if data, _ := os.ReadFile(index); !strings.Contains(string(data), "title: News") {
+		t.Errorf("_index.md:\n%s", data)
+	}
+	if fileExists(filepath.Join(content, "blog", "_index.md")) {
+		t.Error("top-level section got an _index.md")
+	}
+
+	writeFeed(howto)
+	if log, err := runMain(t, append(args, "-clean", "-yes")...); err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	if fileExists(news) || fileExists(index) {
+		t.Error("-clean left the routed post of the earlier run")
+	}
+}
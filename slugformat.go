@@ -0,0 +1,85 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// slugFormatData is what a -slug-format template sees.
+type slugFormatData struct {
+	Year, Month, Day string
+	Slug             string // sanitized post name without the date prefix
+}
+
+func parseSlugFormat(src string) (*template.Template, error) {
+	return template.New("slug").Option("missingkey=error").Parse(src)
+}
+
+// formatSlug runs the -slug-format template and returns the file name (without
+// extension) below -out. Slashes create sub-directories; the result may not
+// leave -out.
+func formatSlug(t *template.Template, data slugFormatData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(buf.String())
+	name = path.Clean("/" + strings.TrimSuffix(name, "/"))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || name == "." {
+		return "", fmt.Errorf("empty file name")
+	}
+	return name, nil
+}
+
+// nameSet records the file and media folder names taken during the run.
+type nameSet struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+func newNameSet() *nameSet { return &nameSet{used: map[string]bool{}} }
+
+// claim takes all names at once and reports whether they were all free.
+// Names are compared case-insensitively, like on macOS and Windows disks.
+func (s *nameSet) claim(names ...string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, n := range names {
+		if s.used[strings.ToLower(n)] {
+			return false
+		}
+	}
+	for _, n := range names {
+		s.used[strings.ToLower(n)] = true
+	}
+	return true
+}
+
+// reserve claims the names of a post kept from an earlier run (-resume), so
+// new posts don't take them. bundles is -bundles, whose pages are index.md.
+func (s *nameSet) reserve(rec *postRecord, outDir string, bundles bool) {
+	names := []string{}
+	if rel, err := filepath.Rel(outDir, rec.File); err == nil {
+		rel = filepath.ToSlash(rel)
+		rel = strings.TrimSuffix(rel, path.Ext(rel))
+		if bundles {
+			rel = strings.TrimSuffix(rel, "/index")
+		}
+		names = append(names, rel)
+	}
+	if len(rec.Assets) > 0 { // all media of a post share one folder
+		names = append(names, "media/"+filepath.Base(filepath.Dir(rec.Assets[0].Dest)))
+	}
+	for _, n := range names {
+		s.claim(n)
+	}
+}
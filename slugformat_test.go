@@ -0,0 +1,101 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlugFormat(t *testing.T) {
+	out := t.TempDir()
+	setFlag(t, "out", out)
+	setFlag(t, "v", "false")
+	old := conv.slugFormatTmpl
+	t.Cleanup(func() { conv.slugFormatTmpl = old })
+
+	item := Item{Title: "Post", Link: "https://example.com/2024/03/05/my-post/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000"}
+	tests := []struct {
+		format, want string
+	}{
+		{"", "2024-03-my-post.md"},
+		{"{{.Year}}/{{.Month}}/{{.Slug}}", "2024/03/my-post.md"},
+		{"{{.Slug}}", "my-post.md"},
+		{"../{{.Day}}-{{.Slug}}", "05-my-post.md"},
+	}
+	for _, tt := range tests {
+		conv.slugFormatTmpl = nil
+		if tt.format != "" {
+			tmpl, err := parseSlugFormat(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conv.slugFormatTmpl = tmpl
+		}
+		rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.format, err)
+		}
+		if want := filepath.Join(out, filepath.FromSlash(tt.want)); rec.File != want {
+			t.Errorf("%q: file = %s, want %s", tt.format, rec.File, want)
+		}
+	}
+
+	tmpl, err := parseSlugFormat("{{if false}}x{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := formatSlug(tmpl, slugFormatData{}); err == nil {
+		t.Error("empty file name accepted")
+	}
+}
+
+func TestFrontMatterSlug(t *testing.T) {
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	item := Item{Title: "Post", Link: "https://example.com/2024/03/05/my-post/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000"}
+	rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "title: Post\nslug: my-post\n"; !strings.Contains(string(data), want) {
+		t.Errorf("got\n%s\nwant it to contain\n%s", data, want)
+	}
+}
+
+func TestSlugCollisions(t *testing.T) {
+	out := t.TempDir()
+	setFlag(t, "out", out)
+	setFlag(t, "v", "false")
+	old := conv.postNames
+	conv.postNames = newNameSet()
+	t.Cleanup(func() { conv.postNames = old })
+
+	// same slug in the same month: the title fallback and a permalink
+	items := []Item{
+		{Title: "Hello", Link: "https://example.com/2024/03/05/hello/"},
+		{Title: "Hello", Link: "https://example.com/?p=12", PubDate: "Tue, 12 Mar 2024 10:00:00 +0000", PostName: "hello"},
+		{Title: "Hello", Link: "https://example.com/2024/03/20/hello/"},
+	}
+	for i, want := range []string{"2024-03-hello", "2024-03-hello-2", "2024-03-hello-3"} {
+		rec, err := conv.processItem(items[i], time.UTC, conv.newDownloader(1, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.File != filepath.Join(out, want+".md") {
+			t.Errorf("item %d: file = %s, want %s.md", i, rec.File, want)
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tail := strings.TrimPrefix(want, "2024-03-"); !strings.Contains(string(data), "slug: "+tail+"\n") {
+			t.Errorf("item %d: front matter lacks slug %s:\n%s", i, tail, data)
+		}
+	}
+}
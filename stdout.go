@@ -0,0 +1,28 @@
+package wordpress2hugo
+
+import (
+	"io"
+	"sync"
+)
+
+// postSink receives the posts with -stdout instead of -out. Documents are
+// separated by a form feed line, which can't occur in the YAML/TOML/JSON front
+// matter, so a script can split on "\f\n".
+type postSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	n  int
+}
+
+func (s *postSink) write(doc []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n > 0 {
+		if _, err := io.WriteString(s.w, "\f\n"); err != nil {
+			return err
+		}
+	}
+	s.n++
+	_, err := s.w.Write(doc)
+	return err
+}
@@ -0,0 +1,63 @@
+package wordpress2hugo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStdout(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link>` +
+		`<content:encoded><![CDATA[<p><img src="` + srv.URL + `/a.jpg"></p>]]></content:encoded></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link><description>World</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	site := filepath.Join(dir, "site")
+	cmd := exec.Command(os.Args[0], "-feed", feedPath, "-out", filepath.Join(site, "content"), "-static", filepath.Join(site, "static"),
+		"-limit", "0", "-stdout", "-report", filepath.Join(site, "report.json"))
+	cmd.Env = append(os.Environ(), "WP2HUGO_RUN_MAIN=1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, stderr.String())
+	}
+
+	docs := strings.Split(string(out), "\f\n")
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents:\n%s", len(docs), out)
+	}
+	for i, want := range []string{"title: First", "title: Second"} {
+		if !strings.HasPrefix(docs[i], "---\n"+want+"\n") {
+			t.Errorf("document %d:\n%s", i, docs[i])
+		}
+	}
+	if !strings.Contains(docs[0], "(/media/2024-02-first/001_a.jpg)") || !strings.HasSuffix(docs[1], "\nWorld\n") {
+		t.Errorf("bodies:\n%s", out)
+	}
+	if strings.Contains(stderr.String(), "would write 2 posts") {
+		t.Errorf("dry-run summary on stderr:\n%s", stderr.String())
+	}
+	if _, err := os.Stat(site); !os.IsNotExist(err) {
+		t.Errorf("-stdout created %s (%v)", site, err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("-stdout made %d requests", hits.Load())
+	}
+}
@@ -0,0 +1,105 @@
+package wordpress2hugo
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// itemSummary is the post's description: the item's excerpt (WXR
+// excerpt:encoded, or a description that is not just the body again) as plain
+// text, else the first paragraph of the converted body. It is cut to
+// -summary-length characters; 0 disables it.
+func (c *Converter) itemSummary(item Item, contentHTML, body string) string {
+	if c.opts.SummaryLength <= 0 {
+		return ""
+	}
+	excerpt := strings.TrimSpace(item.Excerpt)
+	if excerpt == "" {
+		if d := strings.TrimSpace(item.Description); d != strings.TrimSpace(contentHTML) {
+			excerpt = d
+		}
+	}
+	text := wpMoreRe.ReplaceAllString(htmlText(excerpt), "")
+	if text == "" {
+		if c.opts.ContentFormat == "html" {
+			text = firstHTMLParagraph(body)
+		} else {
+			text = firstMarkdownParagraph(body)
+		}
+	}
+	return truncateWords(text, c.opts.SummaryLength)
+}
+
+// wpMoreRe matches the "[…]" / "Continue reading" tail WordPress puts on
+// generated excerpts.
+var wpMoreRe = regexp.MustCompile(`(?i)\s*(\[(…|\.\.\.)\]|…|continue reading.*|weiterlesen.*)$`)
+
+// htmlText is the visible text of an HTML fragment with whitespace collapsed.
+func htmlText(html string) string {
+	if html == "" {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+func firstHTMLParagraph(html string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return ""
+	}
+	var text string
+	doc.Find("p").EachWithBreak(func(_ int, p *goquery.Selection) bool {
+		text = strings.Join(strings.Fields(p.Text()), " ")
+		return text == ""
+	})
+	return text
+}
+
+var (
+	mdImageRe  = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLinkRe   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdMarkupRe = regexp.MustCompile("\\*\\*|__|[*`]|\\\\")
+)
+
+// firstMarkdownParagraph is the plain text of the first block of body that is
+// prose, skipping headings, images, shortcodes, HTML and code.
+func firstMarkdownParagraph(body string) string {
+	for _, block := range strings.Split(body, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" || strings.HasPrefix(block, "#") || strings.HasPrefix(block, "{{") ||
+			strings.HasPrefix(block, "<") || strings.HasPrefix(block, "```") || strings.HasPrefix(block, "|") {
+			continue
+		}
+		block = strings.TrimLeft(block, "> ")
+		block = mdImageRe.ReplaceAllString(block, "")
+		block = mdLinkRe.ReplaceAllString(block, "$1")
+		block = mdMarkupRe.ReplaceAllString(block, "")
+		if text := strings.Join(strings.Fields(block), " "); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// truncateWords shortens s to at most n characters, cutting at a word
+// boundary and ending with "…".
+func truncateWords(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	cut := string(r[:n-1]) // leaves room for the ellipsis
+	if r[n-1] != ' ' {
+		if i := strings.LastIndex(cut, " "); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " ,;:-–") + "…"
+}
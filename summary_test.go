@@ -0,0 +1,66 @@
+package wordpress2hugo
+
+import (
+	"testing"
+)
+
+func TestTruncateWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"Short enough", 20, "Short enough"},
+		{"The quick brown fox jumps", 16, "The quick brown…"},
+		{"The quick brown fox jumps", 15, "The quick…"},
+		{"Ein Satz, mit Komma", 10, "Ein Satz…"},
+		{"Supercalifragilistic", 8, "Superca…"},
+		{"Grüße über Öl", 11, "Grüße über…"},
+	}
+	for _, tt := range tests {
+		if got := truncateWords(tt.in, tt.n); got != tt.want {
+			t.Errorf("truncateWords(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestItemSummary(t *testing.T) {
+	setFlag(t, "summary-length", "40")
+	body := "## Intro\n\n![cover](/media/x/001_a.jpg)\n\nFirst **real** paragraph with [a link](https://example.com) in it.\n\nSecond one."
+	tests := []struct {
+		name string
+		item Item
+		want string
+	}{
+		{"feed excerpt", Item{Description: "<p>A short teaser of the post [&#8230;]</p>", ContentEncoded: "<p>Full</p>"}, "A short teaser of the post"},
+		{"description is the body", Item{Description: "<p>Full</p>"}, "First real paragraph with a link in it."},
+		{"wxr excerpt", Item{Excerpt: "Hand-written excerpt", ContentEncoded: "<p>Full</p>"}, "Hand-written excerpt"},
+		{"escaped markup", Item{Description: "<p>Type &amp;amp; to get &amp;lt;b&amp;gt;</p>", ContentEncoded: "<p>Full</p>"}, "Type &amp; to get &lt;b&gt;"},
+		{"long", Item{Description: "<p>This teaser goes on and on well past the limit</p>", ContentEncoded: "<p>Full</p>"}, "This teaser goes on and on well past…"},
+	}
+	for _, tt := range tests {
+		content := tt.item.ContentEncoded
+		if content == "" {
+			content = tt.item.Description
+		}
+		if got := conv.itemSummary(tt.item, content, body); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	setFlag(t, "summary-length", "0")
+	if got := conv.itemSummary(Item{Description: "teaser"}, "<p>Full</p>", body); got != "" {
+		t.Errorf("-summary-length 0: got %q", got)
+	}
+}
+
+func TestWXRExcerpt(t *testing.T) {
+	raw, err := decodeRawRSS([]byte(`<rss xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel><item>` +
+		`<content:encoded><![CDATA[<p>Body</p>]]></content:encoded><excerpt:encoded><![CDATA[Teaser]]></excerpt:encoded></item></channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := raw.Channel.Items[0].Excerpt; got != "Teaser" {
+		t.Errorf("excerpt = %q", got)
+	}
+}
@@ -0,0 +1,195 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// tableMarkdown renders a <table> per -table-mode: a GFM pipe table, or the
+// raw HTML for "raw" and for tables a pipe table can't express.
+func (c *Converter) tableMarkdown(table *goquery.Selection) (string, bool) {
+	if c.opts.TableMode == "gfm" {
+		if t, ok := gfmTable(table); ok {
+			return t, true
+		}
+	}
+	// drop the markers html-to-markdown sets on links and list items beforehand
+	raw := table.Clone()
+	raw.Find("a[data-index]").RemoveAttr("data-index")
+	raw.Find("li").RemoveAttr("data-converter-list-prefix")
+	h, err := goquery.OuterHtml(raw)
+	if err != nil {
+		return "", false
+	}
+	return h, true
+}
+
+// gfmTable converts a simple table to a pipe table. The first row becomes the
+// header (GFM requires one) and sets the column alignment; colspans are padded
+// with empty cells. Nested tables, captions, rowspans and block content in
+// cells make it give up (ok=false).
+func gfmTable(table *goquery.Selection) (string, bool) {
+	if table.Find("table, caption").Length() > 0 {
+		return "", false
+	}
+	var rows [][]string
+	var aligns []string
+	ok := true
+	table.Find("tr").EachWithBreak(func(_ int, tr *goquery.Selection) bool {
+		var row []string
+		tr.ChildrenFiltered("th, td").EachWithBreak(func(_ int, cell *goquery.Selection) bool {
+			if span(cell, "rowspan") > 1 {
+				ok = false
+				return false
+			}
+			text, simple := cellMarkdown(cell)
+			if !simple {
+				ok = false
+				return false
+			}
+			n := span(cell, "colspan")
+			row = append(row, text)
+			for j := 1; j < n; j++ {
+				row = append(row, "")
+			}
+			if len(rows) == 0 {
+				a := cellAlign(cell)
+				for j := 0; j < n; j++ {
+					aligns = append(aligns, a)
+				}
+			}
+			return true
+		})
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		return ok
+	})
+	if !ok || len(rows) == 0 {
+		return "", false
+	}
+
+	width := 0
+	for _, r := range rows {
+		width = max(width, len(r))
+	}
+	var b strings.Builder
+	for i, r := range rows {
+		for len(r) < width {
+			r = append(r, "")
+		}
+		b.WriteString("| " + strings.Join(r, " | ") + " |\n")
+		if i == 0 {
+			seps := make([]string, width)
+			for j := range seps {
+				a := ""
+				if j < len(aligns) {
+					a = aligns[j]
+				}
+				switch a {
+				case "center":
+					seps[j] = ":---:"
+				case "right":
+					seps[j] = "---:"
+				case "left":
+					seps[j] = ":---"
+				default:
+					seps[j] = "---"
+				}
+			}
+			b.WriteString("| " + strings.Join(seps, " | ") + " |\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), true
+}
+
+// span reads a colspan/rowspan attribute (1 when missing or invalid).
+func span(cell *goquery.Selection, attr string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(attr, "1")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// cellAlign is the cell's align attribute or CSS text-align.
+func cellAlign(cell *goquery.Selection) string {
+	if a := strings.ToLower(strings.TrimSpace(cell.AttrOr("align", ""))); a != "" {
+		return a
+	}
+	for _, decl := range strings.Split(cell.AttrOr("style", ""), ";") {
+		if k, v, found := strings.Cut(decl, ":"); found && strings.TrimSpace(strings.ToLower(k)) == "text-align" {
+			return strings.ToLower(strings.TrimSpace(v))
+		}
+	}
+	return ""
+}
+
+// cellMarkdown renders the inline content of a table cell on one line. Block
+// content (lists, line breaks, several paragraphs, …) reports simple=false.
+func cellMarkdown(cell *goquery.Selection) (string, bool) {
+	if cell.ChildrenFiltered("p").Length() > 1 {
+		return "", false
+	}
+	var render func(s *goquery.Selection) (string, bool)
+	render = func(s *goquery.Selection) (string, bool) {
+		var b strings.Builder
+		simple := true
+		s.Contents().EachWithBreak(func(_ int, c *goquery.Selection) bool {
+			name := goquery.NodeName(c)
+			if name == "#text" {
+				b.WriteString(strings.ReplaceAll(c.Text(), "|", `\|`))
+				return true
+			}
+			if name == "#comment" {
+				return true
+			}
+			if name == "img" {
+				if src := c.AttrOr("src", ""); src != "" {
+					fmt.Fprintf(&b, "![%s](%s)", strings.TrimSpace(c.AttrOr("alt", "")), src)
+				}
+				return true
+			}
+			if name == "code" {
+				fmt.Fprintf(&b, "`%s`", strings.ReplaceAll(c.Text(), "|", `\|`))
+				return true
+			}
+			inner, ok := render(c)
+			if !ok {
+				simple = false
+				return false
+			}
+			t := strings.TrimSpace(inner)
+			switch name {
+			case "a":
+				if href := c.AttrOr("href", ""); href != "" && t != "" {
+					inner = fmt.Sprintf("[%s](%s)", t, href)
+				}
+			case "strong", "b":
+				if t != "" {
+					inner = "**" + t + "**"
+				}
+			case "em", "i":
+				if t != "" {
+					inner = "*" + t + "*"
+				}
+			case "del", "s", "strike":
+				if t != "" {
+					inner = "~~" + t + "~~"
+				}
+			case "p", "span", "small", "sup", "sub", "mark", "u", "abbr", "time", "font", "label":
+			default:
+				simple = false
+				return false
+			}
+			b.WriteString(inner)
+			return true
+		})
+		return b.String(), simple
+	}
+	text, ok := render(cell)
+	return strings.Join(strings.Fields(text), " "), ok
+}
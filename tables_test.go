@@ -0,0 +1,41 @@
+package wordpress2hugo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	setFlag(t, "v", "false")
+	pricing := `<figure class="wp-block-table"><table><thead><tr><th>Plan</th><th style="text-align:right">Price</th></tr></thead>` +
+		`<tbody><tr><td><strong>Basic</strong></td><td style="text-align:right">5 €</td></tr>` +
+		`<tr><td><a href="https://example.com/pro">Pro</a> | Team</td><td>15 €</td></tr>` +
+		`<tr><td colspan="2">All prices incl. VAT</td></tr></tbody></table></figure>`
+	complexTable := `<table><tr><td rowspan="2">A</td><td>B</td></tr><tr><td>C</td></tr></table>`
+	blocks := `<table><tr><td><ul><li>x</li></ul></td></tr></table>`
+
+	tests := []struct {
+		name, mode, in, want string
+	}{
+		{"pricing", "gfm", pricing, "| Plan | Price |\n| --- | ---: |\n| **Basic** | 5 € |\n| [Pro](https://example.com/pro) \\| Team | 15 € |\n| All prices incl. VAT |  |"},
+		{"rowspan falls back", "gfm", complexTable, complexTable},
+		{"block content falls back", "gfm", blocks, blocks},
+		{"raw mode", "raw", `<table><tr><td><a href="/a">A</a></td></tr></table>`, `<table><tbody><tr><td><a href="/a">A</a></td></tr></tbody></table>`},
+		{"paragraph after", "gfm", `<table><tr><th>1</th><th>2</th></tr></table><p>After table.</p>`, "| 1 | 2 |\n| --- | --- |\n\nAfter table."},
+		{"paragraph after raw", "raw", `<table><tr><td>1</td></tr></table><p>After table.</p>`, "<table><tr><td>1</td></tr></table>\n\nAfter table."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, "table-mode", tt.mode)
+			got, err := conv.toMarkdownPreserveOrder(tt.in, "slug")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.ReplaceAll(tt.want, "<table><tr>", "<table><tbody><tr>")
+			want = strings.ReplaceAll(want, "</tr></table>", "</tr></tbody></table>")
+			if got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // decoder
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseThumbSize parses "WIDTHxHEIGHT"; either side may be left out ("400x")
+// to scale by the other one, which only makes sense for fit.
+func parseThumbSize(s string) (w, h int, err error) {
+	ws, hs, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("want WIDTHxHEIGHT, got %q", s)
+	}
+	if ws != "" {
+		if _, err := fmt.Sscanf(ws, "%d", &w); err != nil || w < 1 {
+			return 0, 0, fmt.Errorf("bad width in %q", s)
+		}
+	}
+	if hs != "" {
+		if _, err := fmt.Sscanf(hs, "%d", &h); err != nil || h < 1 {
+			return 0, 0, fmt.Errorf("bad height in %q", s)
+		}
+	}
+	if w == 0 && h == 0 {
+		return 0, 0, fmt.Errorf("want WIDTHxHEIGHT, got %q", s)
+	}
+	return w, h, nil
+}
+
+var thumbExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true}
+
+// thumbPath is where the thumbnail of an image goes: name-thumb.jpg next to
+// it (PNG for PNG and GIF sources, to keep transparency).
+func thumbPath(src string) string {
+	ext := strings.ToLower(filepath.Ext(src))
+	out := ".jpg"
+	if ext == ".png" || ext == ".gif" {
+		out = ".png"
+	}
+	return strings.TrimSuffix(src, filepath.Ext(src)) + "-thumb" + out
+}
+
+// makeThumbnail writes a resized copy of src to dest. With crop the image is
+// cut to the target aspect ratio and scaled to exactly w×h; otherwise it is
+// scaled down to fit into w×h (never up), keeping its aspect ratio.
+func makeThumbnail(src, dest string, w, h int, crop bool) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return fmt.Errorf("empty image")
+	}
+	if w == 0 {
+		w = sw * h / sh
+	}
+	if h == 0 {
+		h = sh * w / sw
+	}
+
+	area := b
+	if crop && w > 0 && h > 0 {
+		// largest centered region with the target aspect ratio
+		cw, ch := sw, sw*h/w
+		if ch > sh {
+			cw, ch = sh*w/h, sh
+		}
+		x0, y0 := b.Min.X+(sw-cw)/2, b.Min.Y+(sh-ch)/2
+		area = image.Rect(x0, y0, x0+cw, y0+ch)
+	} else {
+		// fit: scale by the tighter side, never enlarge
+		scale := min(float64(w)/float64(sw), float64(h)/float64(sh), 1)
+		w, h = max(1, int(float64(sw)*scale+0.5)), max(1, int(float64(sh)*scale+0.5))
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, area.Dx(), area.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, area.Min, draw.Src)
+	out := resizeBox(rgba, w, h)
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	o, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(dest), ".png") {
+		err = png.Encode(o, out)
+	} else {
+		err = jpeg.Encode(o, out, &jpeg.Options{Quality: 85})
+	}
+	if cerr := o.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// resizeBox scales src to w×h, averaging the source pixels covered by each
+// target pixel (a box filter: simple, and good enough for downscaling).
+func resizeBox(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, bl, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					bl += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j], dst.Pix[j+1], dst.Pix[j+2], dst.Pix[j+3] = uint8(r/n), uint8(g/n), uint8(bl/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+// makeThumbnails waits for the post's image downloads and creates the
+// thumbnails: for the cover (the first image) only, or for every image with
+// -thumbnail-all. It returns the cover thumbnail's file path, "" if none.
+func (c *Converter) makeThumbnails(dl *downloader, rec *postRecord) string {
+	cover := ""
+	for _, a := range rec.Assets {
+		if !thumbExts[strings.ToLower(filepath.Ext(a.Dest))] {
+			continue
+		}
+		if err := dl.Fetch(a.URL, a.Dest); err != nil {
+			continue // already logged by the downloader
+		}
+		dest := thumbPath(a.Dest)
+		if c.dryRun != nil {
+			c.infof("dry-run: would create thumbnail %s", dest)
+		} else if !fileExists(dest) {
+			if err := makeThumbnail(a.Dest, dest, c.thumbSize.w, c.thumbSize.h, c.opts.ThumbnailCrop); err != nil {
+				c.warnf("thumbnail %s: %v", a.Dest, err)
+				continue
+			}
+		}
+		if cover == "" {
+			cover = dest
+		}
+		if !c.opts.ThumbnailAll {
+			break
+		}
+	}
+	return cover
+}
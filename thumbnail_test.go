@@ -0,0 +1,91 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jpg.Bytes())
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		size, crop string
+		w, h       int
+	}{
+		{"100x100", "true", 100, 100},
+		{"100x100", "false", 100, 75},
+		{"200x", "false", 200, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size+" crop="+tt.crop, func(t *testing.T) {
+			static := t.TempDir()
+			setFlag(t, "out", t.TempDir())
+			setFlag(t, "static", static)
+			setFlag(t, "v", "false")
+			setFlag(t, "thumbnail-crop", tt.crop)
+			old := conv.thumbSize
+			t.Cleanup(func() { conv.thumbSize = old })
+			var err error
+			if conv.thumbSize.w, conv.thumbSize.h, err = parseThumbSize(tt.size); err != nil {
+				t.Fatal(err)
+			}
+
+			item := Item{Title: "Post", Link: "https://example.com/2024/03/05/post/",
+				ContentEncoded: `<p>Text</p><p><img src="` + srv.URL + `/cover.jpg"></p><p><img src="` + srv.URL + `/second.jpg"></p>`}
+			dl := conv.newDownloader(2, 2)
+			rec, err := conv.processItem(item, time.UTC, dl)
+			dl.Wait()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(rec.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "thumbnail: /media/2024-03-post/001_cover-thumb.jpg\n"; !strings.Contains(string(data), want) {
+				t.Errorf("front matter lacks %q:\n%s", want, data)
+			}
+			f, err := os.Open(filepath.Join(static, "media", "2024-03-post", "001_cover-thumb.jpg"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			cfg, err := jpeg.DecodeConfig(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.w || cfg.Height != tt.h {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.w, tt.h)
+			}
+			if fileExists(filepath.Join(static, "media", "2024-03-post", "002_second-thumb.jpg")) {
+				t.Error("thumbnail for a non-cover image without -thumbnail-all")
+			}
+		})
+	}
+
+	if _, _, err := parseThumbSize("large"); err == nil {
+		t.Error("parseThumbSize accepted \"large\"")
+	}
+}
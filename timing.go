@@ -0,0 +1,62 @@
+package wordpress2hugo
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// runTimings collects the wall-clock time per stage for -timing.
+type runTimings struct {
+	feed     time.Duration
+	items    []itemTiming
+	dlWait   time.Duration // waiting for downloads after the last item
+	dlBusy   atomic.Int64  // summed duration of all downloads (they overlap)
+	started  time.Time
+	slowestN int
+}
+
+type itemTiming struct {
+	name string
+	d    time.Duration
+}
+
+func (t *runTimings) addItem(name string, d time.Duration) {
+	if t != nil {
+		t.items = append(t.items, itemTiming{name, d})
+	}
+}
+
+func (t *runTimings) addDownload(d time.Duration) {
+	if t != nil {
+		t.dlBusy.Add(int64(d))
+	}
+}
+
+// log logs the breakdown and the slowest items at info level.
+func (t *runTimings) log(lg *logger) {
+	if t == nil {
+		return
+	}
+	var items time.Duration
+	for _, it := range t.items {
+		items += it.d
+	}
+	r := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	lg.infof("timing: total %v", r(time.Since(t.started)))
+	lg.infof("timing:   feed load          %v", r(t.feed))
+	lg.infof("timing:   items (%d)          %v", len(t.items), r(items))
+	lg.infof("timing:   download wait      %v (downloads busy %v in total)", r(t.dlWait), r(time.Duration(t.dlBusy.Load())))
+
+	slow := append([]itemTiming(nil), t.items...)
+	sort.SliceStable(slow, func(i, j int) bool { return slow[i].d > slow[j].d })
+	if len(slow) > t.slowestN {
+		slow = slow[:t.slowestN]
+	}
+	if len(slow) > 0 {
+		lg.infof("timing: slowest items")
+	}
+	for _, it := range slow {
+		lg.infof("timing:   %-10v %s", r(it.d), it.name)
+	}
+}
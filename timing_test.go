@@ -0,0 +1,30 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTimingSummary(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link><description>a</description></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link><description>b</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(dir, "posts"), "-static", filepath.Join(dir, "static"),
+		"-limit", "0", "-yes", "-v=false", "-timing")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	for _, want := range []string{"timing: total ", "feed load", "items (2)", "download wait", "timing: slowest items", " First\n", " Second\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary lacks %q:\n%s", want, out)
+		}
+	}
+}
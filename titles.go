@@ -0,0 +1,47 @@
+package wordpress2hugo
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// normalizeTitle cleans up a feed title for the front matter: it decodes the
+// HTML entities some feeds leave in (&amp;, &rsquo;), collapses whitespace and
+// applies -title-case. Shouted titles (no lowercase letter) are lowercased
+// first. The slug is derived from the item, not from this title.
+func normalizeTitle(s, mode string) string {
+	s = strings.Join(strings.Fields(htmlUnescape(s)), " ")
+	if mode != "title" && mode != "sentence" {
+		return s
+	}
+	if !strings.ContainsFunc(s, unicode.IsLower) {
+		s = strings.ToLower(s)
+	}
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		switch {
+		case i == 0 || mode == "title" && !strings.ContainsFunc(w, unicode.IsUpper):
+			words[i] = upperFirst(w) // iPhone stays
+		case mode == "sentence" && capitalizedWord(w):
+			words[i] = strings.ToLower(w) // sentence case; API, iPhone and I stay
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func upperFirst(w string) string {
+	if w == "" {
+		return w
+	}
+	r, n := utf8.DecodeRuneInString(w)
+	return string(unicode.ToUpper(r)) + w[n:]
+}
+
+// capitalizedWord reports whether w is a word with only its first letter
+// upper case, like "Title" (not "I", "API" or "iPhone").
+func capitalizedWord(w string) bool {
+	r, n := utf8.DecodeRuneInString(w)
+	return unicode.IsUpper(r) && utf8.RuneCountInString(w) > 1 &&
+		!strings.ContainsFunc(w[n:], unicode.IsUpper) && strings.ContainsFunc(w[n:], unicode.IsLower)
+}
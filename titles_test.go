@@ -0,0 +1,25 @@
+package wordpress2hugo
+
+import "testing"
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		in, mode, want string
+	}{
+		{"  Tom &amp; Jerry  ", "none", "Tom & Jerry"},
+		{"Don&rsquo;t  panic &#8211; ever", "none", "Don’t panic – ever"},
+		{"HELLO WORLD", "none", "HELLO WORLD"},
+		{"HELLO WORLD", "title", "Hello World"},
+		{"HELLO WORLD", "sentence", "Hello world"},
+		{"how to use the API on an iPhone", "title", "How To Use The API On An iPhone"},
+		{"How To Use The API On An iPhone", "sentence", "How to use the API on an iPhone"},
+		{"Was I Right?", "sentence", "Was I right?"},
+		{"über Straßen", "title", "Über Straßen"},
+		{"", "title", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.in, tt.mode); got != tt.want {
+			t.Errorf("normalizeTitle(%q, %s) = %q, want %q", tt.in, tt.mode, got, tt.want)
+		}
+	}
+}
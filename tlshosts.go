@@ -0,0 +1,51 @@
+package wordpress2hugo
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func parseHostList(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, h := range strings.Split(s, ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			m[h] = true
+		}
+	}
+	return m
+}
+
+// withTLSHosts makes t skip certificate verification for the -skip-tls-hosts
+// only. The TLS config is chosen per connection in the dialer, since a shared
+// tls.Config cannot tell hosts apart (IP addresses send no SNI).
+func (c *Converter) withTLSHosts(t *http.Transport) *http.Transport {
+	if len(c.skipTLSHosts) == 0 {
+		return t
+	}
+	t.ForceAttemptHTTP2 = true
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		d := &tls.Dialer{Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: c.skipTLSHosts[strings.ToLower(host)],
+			NextProtos:         []string{"h2", "http/1.1"},
+		}}
+		return d.DialContext(ctx, network, addr)
+	}
+	return t
+}
+
+// newHTTPClient is the client for feed and API requests.
+func (c *Converter) newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: c.withTLSHosts(&http.Transport{Proxy: http.ProxyFromEnvironment}),
+	}
+}
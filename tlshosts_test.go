@@ -0,0 +1,42 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSkipTLSHosts(t *testing.T) {
+	// the test server's certificate is self-signed, so only a skipped host gets through
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // rejected handshakes are expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		hosts   string
+		wantErr bool
+	}{
+		{"", true},
+		{"internal.example", true},
+		{"internal.example, 127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		old := conv.skipTLSHosts
+		conv.skipTLSHosts = parseHostList(tt.hosts)
+		resp, err := conv.newHTTPClient(5 * time.Second).Get(srv.URL)
+		conv.skipTLSHosts = old
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("-skip-tls-hosts %q: err = %v, want error %v", tt.hosts, err, tt.wantErr)
+		}
+	}
+}
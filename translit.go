@@ -0,0 +1,42 @@
+package wordpress2hugo
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// translitLetters are the Latin letters spelled out rather than stripped of
+// their accent (German umlauts) or that have no decomposition at all.
+var translitLetters = map[rune]string{
+	'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss",
+	'Ä': "ae", 'Ö': "oe", 'Ü': "ue", 'ẞ': "ss",
+	'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe", 'ø': "o", 'Ø': "o",
+	'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d", 'ł': "l", 'Ł': "l",
+	'þ': "th", 'Þ': "th", 'ı': "i", 'ħ': "h", 'Ħ': "h",
+}
+
+// transliterate maps Latin letters with diacritics to ASCII (ä → ae, é → e,
+// ç → c) so slugs stay readable. Other scripts pass through unchanged.
+func transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range norm.NFC.String(s) {
+		if t, ok := translitLetters[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+			continue
+		}
+		for _, d := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, d) { // drop the accent split off by NFD
+				b.WriteRune(d)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package wordpress2hugo
+
+import (
+	"encoding/csv"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// writeURLMap writes a CSV of old_url,new_url pairs: one row for each post's
+// original link and one for each of its aliases.
+func (c *Converter) writeURLMap(p string, recs []*postRecord) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"old_url", "new_url"})
+	for _, rec := range recs {
+		if rec.File == "" {
+			continue
+		}
+		newURL := c.pagePath(rec.File)
+		linkPath := ""
+		if u, err := url.Parse(rec.Link); err == nil && rec.Link != "" {
+			linkPath = ensureTrailingSlash(u.Path)
+			w.Write([]string{rec.Link, newURL})
+		}
+		for _, a := range rec.redirectPaths() {
+			if a != linkPath {
+				w.Write([]string{a, newURL})
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// pagePath is the URL path Hugo gives a content file with default permalinks:
+// its path below content/ without the extension ("/posts/2024-03-title/").
+// Without a content/ directory in the path, -out is taken as the content root.
+func (c *Converter) pagePath(file string) string {
+	rel := c.contentPath(file)
+	rel = strings.TrimSuffix(rel, path.Ext(rel))
+	if path.Base(rel) == "index" { // leaf bundle (-bundles)
+		rel = path.Dir(rel)
+	}
+	return ensureTrailingSlash("/" + strings.TrimPrefix(rel, "/"))
+}
+
+// contentPath is file's slash-separated path below content/ (or -out), like
+// "posts/2024-03-title.md".
+func (c *Converter) contentPath(file string) string {
+	rel := filepath.ToSlash(file)
+	if i := strings.LastIndex("/"+rel, "/content/"); i >= 0 {
+		return strings.TrimPrefix(rel[i+len("content/"):], "/")
+	}
+	if r, err := filepath.Rel(c.opts.Out, file); err == nil {
+		return filepath.ToSlash(r)
+	}
+	return rel
+}
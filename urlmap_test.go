@@ -0,0 +1,37 @@
+package wordpress2hugo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestURLMap(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link><description>a</description></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/?ref=x</link><description>b</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	csvPath := filepath.Join(dir, "urlmap.csv")
+	log, err := runMain(t, "-feed", feedPath, "-out", filepath.Join(dir, "site", "content", "posts"), "-static", filepath.Join(dir, "site", "static"),
+		"-limit", "0", "-yes", "-urlmap", csvPath, "-alias-template", "/blog/{{.Name}}/")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	got, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "old_url,new_url\n" +
+		"https://example.com/2024/02/01/first/,/posts/2024-02-first/\n" +
+		"/blog/first/,/posts/2024-02-first/\n" +
+		"https://example.com/2024/01/15/second/?ref=x,/posts/2024-01-second/\n" +
+		"/blog/second/,/posts/2024-01-second/\n"
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
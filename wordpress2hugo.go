@@ -1,28 +1,36 @@
-package main
+package wordpress2hugo
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
+	"html"
 	"io"
-	"log"
 	"math/rand"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/mmcdole/gofeed"
-	"gopkg.in/yaml.v3"
 )
 
 // RSS structs with namespace support
@@ -33,94 +41,661 @@ type RSS struct {
 }
 
 type Channel struct {
-	Title string `xml:"title"`
-	Items []Item `xml:"item"`
+	Title      string       `xml:"title"`
+	Items      []Item       `xml:"item"`
+	Categories []WPCategory `xml:"category"` // wp:category (WXR exports)
 }
 
 type Item struct {
 	Title           string     `xml:"title"`
 	Link            string     `xml:"link"`
 	PubDate         string     `xml:"pubDate"`
+	Updated         string     `xml:"-"` // RFC3339 modification time (atom:updated, REST modified_gmt)
 	GUID            string     `xml:"guid"`
 	Creator         string     `xml:"{http://purl.org/dc/elements/1.1/}creator"`
 	Description     string     `xml:"description"`
+	Excerpt         string     `xml:"http://wordpress.org/export/1.2/excerpt/ encoded"` // excerpt:encoded (WXR)
 	ContentEncoded  string     `xml:"{http://purl.org/rss/1.0/modules/content/}encoded"`
 	Categories      []Category `xml:"category"`
 	CommentsFeedURL string     `xml:"{http://wellformedweb.org/CommentAPI/}commentRss"`
+	PostName        string     `xml:"post_name"`         // wp:post_name (WXR exports)
+	PostDate        string     `xml:"post_date"`         // wp:post_date, blog-local "2006-01-02 15:04:05" (WXR)
+	PostDateGMT     string     `xml:"post_date_gmt"`     // wp:post_date_gmt (WXR; zero for drafts)
+	Status          string     `xml:"status"`            // wp:status: publish, draft, pending, private, … (WXR)
+	PostType        string     `xml:"post_type"`         // wp:post_type: post, page, attachment, … (WXR)
+	PostModified    string     `xml:"post_modified"`     // wp:post_modified, blog-local (WXR)
+	PostModifiedGMT string     `xml:"post_modified_gmt"` // wp:post_modified_gmt (WXR)
+	Cover           string     `xml:"-"`                 // featured image URL (media:thumbnail/media:content, REST featured media)
+	SourceName      string     `xml:"-"`                 // -source-name of the feed the item came from
+	BaseURL         string     `xml:"-"`                 // feed URL that relative URLs resolve against when the item has no link
+
+	CategoryPaths map[string][]string `xml:"-"` // nested category name -> names from the root
+	Enclosures    []Enclosure         `xml:"enclosure"`
+}
+
+// Enclosure is a file attached to a feed item (podcast audio, …).
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
 }
 
 type Category struct {
-	Domain string `xml:"domain,attr"`
-	Value  string `xml:",chardata"`
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Value    string `xml:",chardata"`
 }
 
-// Front matter structure for YAML
+// Front matter structure (marshaled via toMap so taxonomy keys are configurable)
 
 type FrontMatter struct {
 	Title      string    `yaml:"title"`
+	Slug       string    `yaml:"slug,omitempty"`
 	Date       time.Time `yaml:"date"`
+	LastMod    time.Time `yaml:"lastmod,omitempty"` // only when after Date
 	Draft      bool      `yaml:"draft"`
-	Tags       []string  `yaml:"tags"`
+	Author     string    `yaml:"author,omitempty"`
+	Summary    string    `yaml:"description,omitempty"` // see -summary-length
+	Tags       []string  `yaml:"-"`
 	Aliases    []string  `yaml:"aliases"`
-	Categories []string  `yaml:"categories"`
+	Canonical  string    `yaml:"canonicalURL,omitempty"` // original post URL, see -canonical
+	Categories []string  `yaml:"-"`
+	Source     string    `yaml:"-"` // -source-name of the item's feed, under -source-key
+	Kind       string    `yaml:"-"` // mapped post format, see -format-map
+	Thumbnail  string    `yaml:"-"`
+	Cover      string    `yaml:"-"` // featured image, under -cover-key
+	Extra      *fmMap    `yaml:"-"` // output of -frontmatter-template
+
+	ReadingTime int `yaml:"-"` // minutes, see -reading-time
+	WordCount   int `yaml:"-"`
 }
 
-var (
-	feedURL     = flag.String("feed", "https://blog.breyer.berlin/feed/", "RSS feed URL or file path")
-	outDir      = flag.String("out", "content/posts", "Output directory for Hugo Markdown files")
-	staticDir   = flag.String("static", "static", "Hugo static directory (root of images/galleries)")
-	timezone    = flag.String("tz", "Europe/Berlin", "IANA timezone for front matter dates, e.g. Europe/Berlin")
-	limitItems  = flag.Int("limit", 1, "Process only the first N items (0 = all)")
-	concurrency = flag.Int("concurrency", 6, "Concurrent image download workers")
-	timeoutSec  = flag.Int("timeout", 120, "Per-request download timeout in seconds")
-	retries     = flag.Int("retries", 3, "Number of download retries on failure")
-	perHost     = flag.Int("perhost", 4, "Max concurrent downloads per host")
-	verbose     = flag.Bool("v", true, "Verbose output")
-	clean       = flag.Bool("clean", true, "Delete output folders (content/posts and static/images|galleries) before run")
-)
+// ErrDownloadsFailed is returned (wrapped) by Run and Convert when media
+// downloads failed after their retries; the posts are written regardless.
+var ErrDownloadsFailed = errors.New("media downloads failed")
+
+// Main runs the command line: it parses args (without the program name)
+// into Options, runs them and returns the exit code.
+func Main(args []string) int {
+	fs := flag.NewFlagSet("wordpress2hugo", flag.ContinueOnError)
+	var o Options
+	o.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	c, err := NewConverter(o)
+	if err != nil {
+		(&logger{json: o.LogJSON}).errorf("%v", err)
+		return 1
+	}
+	defer c.Close()
+	if err := c.Run(); err != nil {
+		c.errorf("%v", err)
+		return 1
+	}
+	return 0
+}
+
+// Converter converts feed items into Hugo pages with the Options it was made
+// with. It holds everything a conversion needs besides them (parsed flags,
+// rate limiters, the names and aliases taken so far, …), so Converters for
+// different sites can work side by side. A Converter runs one Run or
+// Convert at a time; Close it when done.
+type Converter struct {
+	logger
+	opts *Options
+
+	aliasTemplates  []*template.Template // -alias-template
+	fmTemplate      *template.Template   // -frontmatter-template, nil when unset
+	galleryTemplate *template.Template   // -gallery-shortcode, nil when unset
+	slugFormatTmpl  *template.Template   // -slug-format, nil: YYYY-MM-name file names
+	formatMap       map[string]string    // -format-map, empty: no format field
+	sectionRoutes   []sectionRoute       // -section-by-category, in flag order
+	thumbSize       struct{ w, h int }   // -thumbnail, zero when off
+	// requestHeaders are the -header flags, sent with the feed requests and
+	// every media download. Like -feed-user/-feed-pass they are never logged.
+	requestHeaders http.Header
+	// skipTLSHosts are the hosts (-skip-tls-hosts) whose certificates are not
+	// verified, e.g. internal servers with self-signed certificates.
+	skipTLSHosts map[string]bool
+	ffmpegPath   string // found for -gif-to-mp4; empty disables conversion
+
+	globalLimiter *rateLimiter // all outbound HTTP requests together, -global-rate
+	limiter       *rateLimiter // media downloads, -rate
+	mediaCache    *diskCache   // -cache-dir, nil when unset
+	// robots holds the robots.txt rules per origin for -respect-robots (nil
+	// when off). Each origin's robots.txt is fetched once, on its first image.
+	robots *robotsCache
+	// feedCache remembers the ETag and Last-Modified of remote feeds between
+	// runs (-feed-state). nil disables conditional requests.
+	feedCache *feedState
+
+	// The state of the current Run or Convert, set up by start. The nil ones
+	// are off; their methods are no-ops on nil.
+	dryRun      *dryRunCounts // -dry-run or -stdout
+	stdoutPosts *postSink     // -stdout
+	timings     *runTimings   // -timing
+	flatMedia   *flatNameSet  // -flatten-images
+	// aliasOwners records which post claimed each alias, since Hugo refuses
+	// to build when two pages share one. nil: no tracking.
+	aliasOwners map[string]string
+	postNames   *nameSet // file and media folder names taken, nil: no tracking
+}
+
+// NewConverter checks o and sets up a Converter for it.
+func NewConverter(o Options) (*Converter, error) {
+	c := &Converter{opts: &o}
+	if err := c.configure(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close stops the rate limiters of c.
+func (c *Converter) Close() {
+	c.globalLimiter.Stop()
+	c.limiter.Stop()
+}
+
+// configure checks c.opts and sets up the state it implies (log level,
+// downloads, templates, …).
+func (c *Converter) configure() error {
+	o := c.opts
+	c.json = o.LogJSON
+	l, err := parseLogLevel(o.LogLevel, o.Verbose)
+	if err != nil {
+		return fmt.Errorf("-log-level %v", err)
+	}
+	c.threshold = l
+
+	c.globalLimiter = newRateLimiter(o.GlobalRate)
+	c.limiter = newRateLimiter(o.Rate)
+	c.mediaCache = newDiskCache(o.CacheDir, o.MaxImageBytes)
+	c.robots = newRobotsCache(o.RespectRobots, c.fetchRobots)
+	c.skipTLSHosts = parseHostList(o.SkipTLSHosts)
+	if c.requestHeaders, err = parseHeaders(o.Headers); err != nil {
+		return fmt.Errorf("-header: %v", err)
+	}
+
+	if o.Resume {
+		if o.Report == "" {
+			return errors.New("-resume requires -report")
+		}
+		if o.Clean {
+			c.infof("resume: not cleaning output folders")
+			o.Clean = false
+		}
+	}
+	if !o.Overwrite && o.Clean {
+		c.infof("-overwrite=false: not cleaning output folders")
+		o.Clean = false
+	}
+
+	if o.DedupeMedia && o.Bundles {
+		return errors.New("-dedupe-media cannot share files between -bundles")
+	}
+	if names, feeds := splitFeeds(o.SourceNames), splitFeeds(o.Feed); len(names) > 0 && len(names) != len(feeds) {
+		return fmt.Errorf("-source-name needs one name per -feed, got %d names for %d feeds", len(names), len(feeds))
+	}
+	if o.FlattenImages && o.Bundles {
+		return errors.New("-flatten-images cannot be combined with -bundles")
+	}
+	switch o.CategoryHierarchy {
+	case "flat", "path", "section":
+	default:
+		return fmt.Errorf("-category-hierarchy must be flat, path or section, got %q", o.CategoryHierarchy)
+	}
+
+	switch o.InternalLinks {
+	case "keep", "ref", "path":
+	default:
+		return fmt.Errorf("-internal-links must be keep, ref or path, got %q", o.InternalLinks)
+	}
+
+	switch o.DateSource {
+	case "pubdate", "content-time":
+	default:
+		return fmt.Errorf("-date-source must be pubdate or content-time, got %q", o.DateSource)
+	}
+	if o.DateFormat == "" {
+		return errors.New("-date-format must be rfc3339, dateonly or a Go time layout")
+	}
+
+	if c.aliasTemplates, err = parseAliasTemplates(o.AliasTemplates); err != nil {
+		return fmt.Errorf("-alias-template: %v", err)
+	}
+
+	c.formatMap = parseFormatMap(o.FormatMap)
+	if c.sectionRoutes, err = parseSectionRoutes(o.SectionByCategory); err != nil {
+		return fmt.Errorf("-section-by-category: %v", err)
+	} else if len(c.sectionRoutes) > 0 && o.CategoryHierarchy == "section" {
+		return errors.New("-section-by-category cannot be combined with -category-hierarchy section")
+	}
+
+	if o.SlugFormat != "" {
+		if c.slugFormatTmpl, err = parseSlugFormat(o.SlugFormat); err != nil {
+			return fmt.Errorf("-slug-format: %v", err)
+		}
+	}
+
+	if o.Thumbnail != "" {
+		w, h, err := parseThumbSize(o.Thumbnail)
+		if err != nil {
+			return fmt.Errorf("-thumbnail: %v", err)
+		}
+		c.thumbSize.w, c.thumbSize.h = w, h
+	}
+
+	switch o.ContentField {
+	case "auto", "content", "description", "longest":
+	default:
+		return fmt.Errorf("-content-field must be auto, content, description or longest, got %q", o.ContentField)
+	}
+
+	switch o.ContentFormat {
+	case "md", "html":
+	default:
+		return fmt.Errorf("-content-format must be md or html, got %q", o.ContentFormat)
+	}
+
+	switch o.FrontMatterFormat {
+	case "yaml", "toml", "json":
+	default:
+		return fmt.Errorf("-frontmatter must be yaml, toml or json, got %q", o.FrontMatterFormat)
+	}
+
+	switch o.TableMode {
+	case "gfm", "raw":
+	default:
+		return fmt.Errorf("-table-mode must be gfm or raw, got %q", o.TableMode)
+	}
+
+	switch o.EmojiSlug {
+	case "code", "name", "drop":
+	default:
+		return fmt.Errorf("-emoji-slug must be code, name or drop, got %q", o.EmojiSlug)
+	}
+
+	switch o.Source {
+	case "rss", "wp-rest":
+	default:
+		return fmt.Errorf("-source must be rss or wp-rest, got %q", o.Source)
+	}
+
+	switch o.TitleCase {
+	case "none", "title", "sentence":
+	default:
+		return fmt.Errorf("-title-case must be none, title or sentence, got %q", o.TitleCase)
+	}
+	switch o.IndexGroup {
+	case "year", "category":
+	default:
+		return fmt.Errorf("-index-group must be year or category, got %q", o.IndexGroup)
+	}
+
+	if o.GalleryShortcode != "" {
+		if c.galleryTemplate, err = parseGalleryTemplate(o.GalleryShortcode); err != nil {
+			return fmt.Errorf("-gallery-shortcode: %v", err)
+		}
+	}
+
+	if o.FrontMatterTemplate != "" {
+		if c.fmTemplate, err = parseFMTemplate(o.FrontMatterTemplate); err != nil {
+			return fmt.Errorf("-frontmatter-template: %v", err)
+		}
+	}
+
+	if o.HugoConfig != "" {
+		cfg, err := loadHugoConfig(o.HugoConfig)
+		if err != nil {
+			return fmt.Errorf("-hugo-config: %v", err)
+		}
+		dated := o.SlugFormat == "" || strings.Contains(o.SlugFormat, ".Year") || strings.Contains(o.SlugFormat, ".Month")
+		keys := []string{o.TagsKey, o.CategoriesKey}
+		if o.SourceNames != "" {
+			keys = append(keys, o.SourceKey)
+		}
+		for _, w := range checkHugoConfig(cfg, o.Out, o.Static, keys, dated) {
+			c.warnf("hugo config: %s", w)
+		}
+	}
 
-func main() {
-	flag.Parse()
+	if o.FeedState != "" {
+		if c.feedCache, err = loadFeedState(o.FeedState); err != nil {
+			return fmt.Errorf("-feed-state: %v", err)
+		}
+	}
 
-	if *clean {
-		if err := cleanOutput(*outDir, *staticDir); err != nil {
-			log.Fatalf("clean output: %v", err)
+	if o.GIFToMP4 && !o.DryRun && !o.Stdout {
+		p, err := exec.LookPath("ffmpeg")
+		if err != nil {
+			c.warnf("-gif-to-mp4: ffmpeg not found, keeping GIFs as they are")
 		}
+		c.ffmpegPath = p
+	}
+	return nil
+}
+
+// location is the -tz time zone, else the local one.
+func (c *Converter) location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		c.warnf("could not load tz %q, using Local: %v", name, err)
+		return time.Local
+	}
+	return loc
+}
+
+// start resets the state of a Run or Convert.
+func (c *Converter) start() {
+	o := c.opts
+	c.timings, c.dryRun, c.stdoutPosts, c.flatMedia = nil, nil, nil, nil
+	if o.Timing {
+		c.timings = &runTimings{started: time.Now(), slowestN: 5}
+	}
+	if o.DryRun || o.Stdout {
+		c.dryRun = &dryRunCounts{}
+	}
+	if o.Stdout {
+		c.stdoutPosts = &postSink{w: os.Stdout}
+	}
+	if o.FlattenImages {
+		c.flatMedia = newFlatNameSet()
+	}
+	c.aliasOwners = map[string]string{}
+	c.postNames = newNameSet()
+}
+
+// newRunDownloader is the media downloader of a run, with deduplication and
+// per-host concurrency.
+func (c *Converter) newRunDownloader() *downloader {
+	dl := c.newDownloader(c.opts.Concurrency, c.opts.PerHost)
+	dl.limiter = c.limiter
+	dl.delay = c.opts.Delay
+	return dl
+}
+
+// Convert turns one feed item into its front matter and page body (Markdown,
+// or HTML with ContentFormat "html") without writing the page. Its media are
+// downloaded below Options.Static like in Run and the body links to them
+// there; the section _index.md files its page would need below Options.Out
+// (-category-hierarchy section, nested -section-by-category routes) are
+// written too.
+func (c *Converter) Convert(item Item) (FrontMatter, string, error) {
+	c.start()
+	dl := c.newRunDownloader()
+	_, _, fm, body, err := c.convertItem(item, c.location(c.opts.Timezone), dl)
+	dl.Wait()
+	if failed := dl.Failures(); err == nil && len(failed) > 0 {
+		err = fmt.Errorf("%w: %s: %v", ErrDownloadsFailed, failed[0].URL, failed[0].Err)
+	}
+	return fm, body, err
+}
+
+// Convert is Convert of a Converter made for o.
+func Convert(item Item, o Options) (FrontMatter, string, error) {
+	c, err := NewConverter(o)
+	if err != nil {
+		return FrontMatter{}, "", err
 	}
-	if err := os.MkdirAll(*outDir, 0o755); err != nil {
-		log.Fatalf("create out dir: %v", err)
+	defer c.Close()
+	return c.Convert(item)
+}
+
+// LoadItems loads the items of the feeds in the Options (Feed, Source,
+// Paginate, …) in the order Run converts them, e.g. to Convert them one by
+// one. With FeedState set, feeds unchanged since it was saved give
+// ErrNotModified.
+func (c *Converter) LoadItems() ([]Item, error) {
+	rss, err := c.loadFeed()
+	if err != nil {
+		return nil, err
 	}
-	if err := os.MkdirAll(*staticDir, 0o755); err != nil {
-		log.Fatalf("create static dir: %v", err)
+	return rss.Channel.Items, nil
+}
+
+func (c *Converter) loadFeed() (*RSS, error) {
+	if c.opts.Source == "wp-rest" {
+		return c.loadFeeds(splitFeeds(c.opts.Feed), c.loadWPREST)
 	}
+	return c.loadFeeds(splitFeeds(c.opts.Feed), c.loadRSSPages)
+}
 
-	rss, err := loadRSS(*feedURL)
+// Run imports the feed(s) of o into the Hugo site like the command does.
+func Run(o Options) error {
+	c, err := NewConverter(o)
 	if err != nil {
-		log.Fatalf("load RSS: %v", err)
+		return err
+	}
+	defer c.Close()
+	return c.Run()
+}
+
+// Run imports the feed(s) in the Options into the Hugo site like the command
+// does.
+func (c *Converter) Run() error {
+	started := time.Now()
+	o := c.opts
+	c.start()
+
+	var previous map[string]*postRecord
+	if o.Resume {
+		var err error
+		if previous, err = loadReport(o.Report); err != nil {
+			return fmt.Errorf("load report: %v", err)
+		}
 	}
 
-	loc, err := time.LoadLocation(*timezone)
+	// The feed comes first: when it has not changed, nothing is cleaned.
+	feedStart := time.Now()
+	rss, err := c.loadFeed()
+	if errors.Is(err, ErrNotModified) {
+		c.infof("feed not modified since the last run (see %s), nothing to do", o.FeedState)
+		return nil
+	}
 	if err != nil {
-		log.Printf("warn: could not load tz %q, using Local: %v", *timezone, err)
-		loc = time.Local
+		return fmt.Errorf("load %s: %v", o.Source, err)
+	}
+	if c.timings != nil {
+		c.timings.feed = time.Since(feedStart)
+	}
+
+	if o.Clean {
+		if err := c.cleanEarlierRun(o.Out, o.Static); err != nil {
+			return fmt.Errorf("clean output: %v", err)
+		}
+	}
+	if c.dryRun == nil {
+		if err := os.MkdirAll(o.Out, 0o755); err != nil {
+			return fmt.Errorf("create out dir: %v", err)
+		}
+		if err := os.MkdirAll(o.Static, 0o755); err != nil {
+			return fmt.Errorf("create static dir: %v", err)
+		}
 	}
 
-	// Image downloader with deduplication and per-host concurrency
-	dl := newDownloader(*concurrency, *perHost)
+	loc := c.location(o.Timezone)
+	dl := c.newRunDownloader()
+	stopProgress := dl.reportProgress(o.Progress)
+	defer stopProgress()
 
 	n := len(rss.Channel.Items)
-	if *limitItems > 0 && *limitItems < n {
-		n = *limitItems
+	if o.Limit > 0 && o.Limit < n {
+		n = o.Limit
 	}
 
+	reportPath := o.Report
+	if c.dryRun != nil {
+		reportPath = "" // a dry run writes no manifest
+	}
+	rep := newReport(reportPath)
+	written, failedItems := 0, 0
 	for i := 0; i < n; i++ {
 		item := rss.Channel.Items[i]
-		if err := processItem(item, loc, dl); err != nil {
-			log.Printf("error processing item %d: %v", i, err)
+		c.setItemPos(fmt.Sprintf("%d/%d ", i+1, n))
+		if prev, ok := previous[itemID(item)]; ok && prev.complete() {
+			c.debugf("resume: skipping %s (complete)", prev.File)
+			c.postNames.reserve(prev, o.Out, o.Bundles)
+			c.flatMedia.reserve(prev)
+			rep.add(prev)
+			continue
+		}
+		if o.SkipExcludedItems && c.onlyExcludedCategories(item.Categories) {
+			c.debugf("skipping %s (only excluded categories)", item.Link)
+			continue
+		}
+		itemStart := time.Now()
+		rec, err := c.processItem(item, loc, dl)
+		c.timings.addItem(item.Title, time.Since(itemStart))
+		if err != nil {
+			c.errorf("processing item: %v", err)
+			failedItems++
+			continue
+		}
+		if !rec.preserved {
+			written++
+		}
+		rep.add(rec)
+		if err := rep.save(dl); err != nil {
+			c.warnf("write report: %v", err)
 		}
 	}
 
+	c.setItemPos("")
+
+	waitStart := time.Now()
 	dl.Wait()
+	stopProgress()
+	if c.timings != nil {
+		c.timings.dlWait = time.Since(waitStart)
+	}
+	if o.DedupeMedia && c.dryRun == nil {
+		moved := dl.Dedupe(preservedMedia(rep.records))
+		if err := c.repointMedia(rep.records, moved); err != nil {
+			c.warnf("dedupe media: %v", err)
+		} else if len(moved) > 0 {
+			c.infof("dedupe: removed %d duplicate media files", len(moved))
+		}
+	}
+	if o.InternalLinks != "keep" && c.dryRun == nil {
+		if n, err := c.rewriteInternalLinks(rep.records); err != nil {
+			c.warnf("internal links: %v", err)
+		} else if n > 0 {
+			c.infof("internal links: pointed %d links at the new pages", n)
+		}
+	}
+	if err := rep.save(dl); err != nil {
+		c.warnf("write report: %v", err)
+	}
+	if c.dryRun != nil {
+		for _, p := range []string{o.Checksums, o.URLMap, o.Redirects, o.OutputIndex} {
+			if p != "" {
+				c.infof("dry-run: would write %s", p)
+			}
+		}
+		if c.stdoutPosts == nil {
+			c.dryRun.log(&c.logger)
+		}
+		c.timings.log(&c.logger)
+		return nil
+	}
+	if o.Checksums != "" {
+		if err := dl.WriteChecksums(o.Checksums); err != nil {
+			c.warnf("write checksums: %v", err)
+		}
+	}
+	if o.URLMap != "" {
+		if err := c.writeURLMap(o.URLMap, rep.records); err != nil {
+			c.warnf("write url map: %v", err)
+		}
+	}
+	if o.Redirects != "" {
+		if err := c.writeRedirects(o.Redirects, rep.records); err != nil {
+			c.warnf("write redirects: %v", err)
+		}
+	}
+	if o.OutputIndex != "" {
+		if err := c.writeIndex(o.OutputIndex, rep.records, o.IndexGroup); err != nil {
+			c.warnf("write index: %v", err)
+		}
+	}
+	c.timings.log(&c.logger)
+	c.logSummary(started, written, failedItems, dl)
+	if failed := dl.Failures(); len(failed) > 0 {
+		for _, f := range failed {
+			c.logFields(levelError, "download failed", "url", f.URL, "dest", f.Dest, "err", f.Err.Error())
+		}
+		return fmt.Errorf("%w: %d", ErrDownloadsFailed, len(failed))
+	}
+	if failedItems > 0 {
+		c.warnf("-feed-state not saved: %d items failed, the next run fetches the feed again", failedItems)
+	} else if err := c.feedCache.save(); err != nil {
+		c.warnf("write feed state: %v", err)
+	}
+	return nil
+}
+
+// cleanEarlierRun removes the files the -report manifest lists below the
+// output folders. Files it does not list (edited elsewhere, or no manifest at
+// all) stop the clean unless -force deletes the folders wholesale.
+func (c *Converter) cleanEarlierRun(contentOut, staticRoot string) error {
+	mediaRoot := filepath.Join(staticRoot, "media")
+	// Posts routed by -section-by-category live next to contentOut
+	contentDirs := append([]string{contentOut}, c.routedDirs(contentOut)...)
+	dirs := append(slices.Clone(contentDirs), mediaRoot)
+	folders := strings.Join(dirs[:len(dirs)-1], ", ") + " and " + mediaRoot
+	prev := map[string]*postRecord{}
+	if c.opts.Report != "" {
+		var err error
+		if prev, err = loadReport(c.opts.Report); err != nil {
+			return fmt.Errorf("load report: %w", err)
+		}
+	}
+	own, foreign, err := cleanTargets(prev, []string{c.opts.OutputIndex}, dirs...)
+	if err != nil {
+		return err
+	}
+	if len(foreign) > 0 && !c.opts.Force {
+		hint := "pass -force to delete the folders anyway"
+		if c.opts.Report == "" {
+			hint = "pass the -report of the earlier run, or -force to delete the folders anyway"
+		}
+		return fmt.Errorf("%d files in %s were not written by an earlier run (e.g. %s); %s",
+			len(foreign), folders, foreign[0], hint)
+	}
+	if c.opts.Force {
+		all := cleanCounts(append(own, foreign...))
+		if c.dryRun != nil {
+			c.infof("dry-run: would clean %s (%s)", folders, all)
+			return nil
+		}
+		if err := c.confirmClean(os.Stdin, os.Stderr, "These directories will be deleted and recreated, with "+all+":", dirs...); err != nil {
+			return err
+		}
+		c.infof("clean: deleting %s (%s)", folders, all)
+		for _, dir := range contentDirs[1:] {
+			if err := removeAndRecreate(dir); err != nil {
+				return fmt.Errorf("reset section dir: %w", err)
+			}
+		}
+		return cleanOutput(contentOut, staticRoot)
+	}
+	if len(own) == 0 {
+		return nil
+	}
+	if c.dryRun != nil {
+		c.infof("dry-run: would clean %s of an earlier run from %s", cleanCounts(own), folders)
+		return nil
+	}
+	if err := c.confirmClean(os.Stdin, os.Stderr, cleanCounts(own)+" an earlier run wrote will be deleted from:", dirs...); err != nil {
+		return err
+	}
+	c.infof("clean: deleting %s of an earlier run", cleanCounts(own))
+	return removeFiles(own, dirs...)
 }
 
 func cleanOutput(contentOut, staticRoot string) error {
@@ -139,6 +714,32 @@ func cleanOutput(contentOut, staticRoot string) error {
 	return nil
 }
 
+// confirmClean asks on the terminal before deleting, showing what and the
+// absolute paths of the directories affected, so a wrong -out or -static
+// stands out. -yes skips the question; without a terminal to ask on,
+// cleaning is refused.
+func (c *Converter) confirmClean(in *os.File, out io.Writer, what string, dirs ...string) error {
+	if c.opts.Yes {
+		return nil
+	}
+	if st, err := in.Stat(); err != nil || st.Mode()&os.ModeCharDevice == 0 {
+		return errors.New("refusing to delete output folders without a terminal to confirm; pass -yes or leave out -clean")
+	}
+	fmt.Fprintln(out, what)
+	for _, d := range dirs {
+		if abs, err := filepath.Abs(d); err == nil {
+			d = abs
+		}
+		fmt.Fprintf(out, "  %s\n", d)
+	}
+	fmt.Fprint(out, "Proceed? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return errors.New("aborted")
+	}
+	return nil
+}
+
 func removeAndRecreate(p string) error {
 	if err := os.RemoveAll(p); err != nil {
 		return err
@@ -146,32 +747,51 @@ func removeAndRecreate(p string) error {
 	return os.MkdirAll(p, 0o755)
 }
 
-func loadRSS(src string) (*RSS, error) {
+func (c *Converter) loadRSS(src string) (*RSS, error) {
 	var r io.ReadCloser
 	var err error
 
 	src = strings.TrimSpace(src)
 	src = strings.TrimPrefix(src, "view-source:") // allow pasted view-source: URLs
 
+	base, encoding := src, ""
 	if fileExists(src) {
 		r, err = os.Open(src)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		client := &http.Client{Timeout: 30 * time.Second}
+		client := c.newHTTPClient(c.opts.FeedTimeout)
 		req, err := http.NewRequest("GET", src, nil)
 		if err != nil {
 			return nil, err
 		}
 		req.Header.Set("Accept", "application/rss+xml, application/xml, text/xml")
+		c.addFeedAuth(req)
+		c.feedCache.addConditions(req, src)
+		c.globalLimiter.Wait()
 		resp, err := client.Do(req)
 		if err != nil {
 			return nil, err
 		}
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return nil, ErrNotModified
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, errFeedNotFound
+		}
 		if resp.StatusCode >= 400 {
+			resp.Body.Close()
 			return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
 		}
+		c.feedCache.remember(src, resp.Header)
+		if final := resp.Request.URL.String(); final != src {
+			c.infof("feed %s redirected to %s (update -feed if it moved for good)", src, final)
+			base = final
+		}
+		encoding = resp.Header.Get("Content-Encoding")
 		r = resp.Body
 	}
 	defer r.Close()
@@ -180,14 +800,24 @@ func loadRSS(src string) (*RSS, error) {
 	if err != nil {
 		return nil, err
 	}
+	if data, err = decodeFeedBody(data, encoding); err != nil {
+		return nil, fmt.Errorf("decompress feed: %w", err)
+	}
+	// Windows-originated feeds may start with a BOM that would leak into titles
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if !utf8.Valid(data) && declaresUTF8(data) {
+		var n int
+		data, n = repairUTF8(data)
+		c.warnf("feed has %d invalid UTF-8 bytes, repaired (read as Latin-1 or dropped)", n)
+	}
 
 	// Try robust feed parsing with gofeed (handles many malformed feeds)
 	fp := gofeed.NewParser()
 	feed, err := fp.ParseString(string(data))
 	if err != nil {
 		// As a fallback, try sanitizing obvious issues and reparse
-		safe := sanitizeXML(data)
-		feed, err = fp.ParseString(string(safe))
+		data = sanitizeXML(data)
+		feed, err = fp.ParseString(string(data))
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse feed: %w", err)
 		}
@@ -199,15 +829,15 @@ func loadRSS(src string) (*RSS, error) {
 		if pub == "" && it.PublishedParsed != nil {
 			pub = it.PublishedParsed.Format(time.RFC1123Z)
 		}
+		updated := ""
+		if it.UpdatedParsed != nil {
+			updated = it.UpdatedParsed.Format(time.RFC3339)
+		}
 		creator := ""
 		if it.Author != nil {
 			creator = strings.TrimSpace(it.Author.Name)
 		}
-		// Prefer full HTML content; fall back to description
-		html := it.Content
-		if strings.TrimSpace(html) == "" {
-			html = it.Description
-		}
+		html := pickContentField(it.Content, it.Description, c.opts.ContentField)
 
 		// Categories: gofeed gives plain strings (domain attr from WP isn't preserved)
 		cats := make([]Category, 0, len(it.Categories))
@@ -219,6 +849,15 @@ func loadRSS(src string) (*RSS, error) {
 			cats = append(cats, Category{Value: c})
 		}
 
+		// Featured image: media:thumbnail/media:content, else an image enclosure
+		encs := enclosures(it.Enclosures)
+		cover := mediaImageURL(it.Extensions)
+		for _, e := range encs {
+			if cover == "" && e.kind() == "image" {
+				cover = e.URL
+			}
+		}
+
 		// Comments feed (best-effort via extensions)
 		commentsURL := ""
 		if extNS, ok := it.Extensions["wfw"]; ok {
@@ -231,17 +870,93 @@ func loadRSS(src string) (*RSS, error) {
 			Title:           it.Title,
 			Link:            it.Link,
 			PubDate:         pub,
+			Updated:         updated,
 			GUID:            it.GUID,
 			Creator:         creator,
 			Description:     it.Description,
 			ContentEncoded:  html,
 			Categories:      cats,
 			CommentsFeedURL: commentsURL,
+			Cover:           cover,
+			Enclosures:      encs,
 		})
 	}
+
+	// WordPress extras (best-effort; plain feeds simply have none)
+	if raw, err := decodeRawRSS(data); err == nil {
+		mergeRawXML(out, raw)
+		out.Channel.Items = c.wxrContentItems(out.Channel.Items)
+	} else {
+		c.debugf("raw XML pass skipped: %v", err)
+	}
+	resolveItemURLs(out.Channel.Items, feedBase(base, feed.Link))
 	return out, nil
 }
 
+var xmlEncodingRe = regexp.MustCompile(`^\s*<\?xml[^>]*encoding=["']([^"']+)["']`)
+
+// declaresUTF8 reports whether the XML declaration names UTF-8 (or no encoding,
+// which means UTF-8). Feeds declaring another charset are decoded by the parser.
+func declaresUTF8(data []byte) bool {
+	m := xmlEncodingRe.FindSubmatch(data[:min(len(data), 200)])
+	if m == nil {
+		return true
+	}
+	enc := strings.ToLower(string(m[1]))
+	return enc == "utf-8" || enc == "utf8"
+}
+
+// repairUTF8 fixes invalid UTF-8 byte by byte: a stray byte from the Latin-1
+// range is taken as that character (mixed-encoding feeds), anything else
+// (C1 controls) is dropped. It returns the number of bytes repaired.
+func repairUTF8(b []byte) ([]byte, int) {
+	out := make([]byte, 0, len(b)+16)
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			n++
+			if b[0] >= 0xA0 {
+				out = utf8.AppendRune(out, rune(b[0]))
+			}
+		} else {
+			out = append(out, b[:size]...)
+		}
+		b = b[size:]
+	}
+	return out, n
+}
+
+// pickContentField chooses the post body from a feed item's content and
+// description (Atom: content and summary), see -content-field.
+func pickContentField(content, description, mode string) string {
+	switch mode {
+	case "content":
+		return content
+	case "description":
+		return description
+	case "longest":
+		if textLen(description) > textLen(content) {
+			return description
+		}
+		return content
+	}
+	// auto: prefer full HTML content; fall back to description
+	if strings.TrimSpace(content) == "" {
+		return description
+	}
+	return content
+}
+
+// textLen is the length of the visible text of an HTML fragment.
+func textLen(html string) int {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return len(html)
+	}
+	return len(strings.TrimSpace(doc.Text()))
+}
+
 func sanitizeXML(b []byte) []byte {
 	s := string(b)
 	s = removeInvalidXMLChars(s)
@@ -323,104 +1038,281 @@ func removeInvalidXMLChars(s string) string {
 	return out.String()
 }
 
-func processItem(item Item, loc *time.Location, dl *downloader) error {
+// processItem converts item and writes its page.
+func (c *Converter) processItem(item Item, loc *time.Location, dl *downloader) (*postRecord, error) {
+	rec, outName, fm, body, err := c.convertItem(item, loc, dl)
+	if err != nil {
+		return nil, err
+	}
+	rec.preserved = !c.opts.Overwrite && fileExists(c.markdownPath(outName))
+	outPath, err := c.writeMarkdownFile(outName, fm, body)
+	if err != nil {
+		return nil, err
+	}
+	rec.File = outPath
+
+	c.infof("✓ %s -> %s (%d chars)", item.Title, filepath.Base(outPath), len(body))
+	return rec, nil
+}
+
+// convertItem builds the front matter and body of item, scheduling its media
+// on dl, and returns them with the page name below -out.
+func (c *Converter) convertItem(item Item, loc *time.Location, dl *downloader) (rec *postRecord, outName string, fm FrontMatter, body string, err error) {
 	u, err := url.Parse(strings.TrimSpace(item.Link))
 	if err != nil {
-		return fmt.Errorf("parse link: %w", err)
+		return nil, "", FrontMatter{}, "", fmt.Errorf("parse link: %w", err)
 	}
 	aliasPath := ensureTrailingSlash(u.Path)
 	year, month, slugTail := extractPathParts(u.Path)
-	if year == "" || month == "" || slugTail == "" {
-		// fallback to date + normalized title
-		if *verbose {
-			log.Printf("fallback slug logic for link=%s", item.Link)
+	if name := c.postNameSlug(item.PostName); name != "" {
+		// <wp:post_name> is the real slug; the permalink only contributes the date parts
+		if year == "" || month == "" {
+			year, month = itemYearMonth(item, loc)
 		}
-		year, month = pubDateYearMonth(item.PubDate, loc)
-		slugTail = slugify(path.Base(strings.Trim(u.Path, "/")))
+		slugTail = name
+	} else if year == "" || month == "" || slugTail == "" {
+		// fallback to date + normalized title
+		c.debugf("fallback slug logic for link=%s", item.Link)
+		year, month = itemYearMonth(item, loc)
+		slugTail = c.slugify(path.Base(strings.Trim(u.Path, "/")))
 	} else {
 		// sanitize slug from URL (remove emojis, spaces, etc.)
-		slugTail = slugify(slugTail)
+		slugTail = c.slugify(slugTail)
 	}
-	slug := fmt.Sprintf("%s-%s-%s", year, month, slugTail)
-
 	contentHTML := strings.TrimSpace(item.ContentEncoded)
 	if contentHTML == "" {
 		contentHTML = strings.TrimSpace(item.Description)
 	}
 
-	processedHTML, err := rewriteAndDownloadImages(contentHTML, slug, dl)
-	if err != nil {
-		return fmt.Errorf("rewrite images: %w", err)
-	}
-
-	bodyMD, err := toMarkdownPreserveOrder(processedHTML, slug)
+	postTime, err := itemTime(item, loc)
 	if err != nil {
-		return fmt.Errorf("html->md: %w", err)
+		c.warnf("pubDate parse failed, using now: %v", err)
+		postTime = time.Now().In(loc)
 	}
-
-	postTime, err := parsePubDate(item.PubDate, loc)
-	if err != nil {
-		if *verbose {
-			log.Printf("warn: pubDate parse failed, using now: %v", err)
+	if c.opts.DateSource == "content-time" {
+		if t, ok := contentTime(contentHTML, loc); ok {
+			postTime = t
+		} else {
+			c.debugf("no <time datetime> in %s, using pubDate", item.Link)
 		}
-		postTime = time.Now().In(loc)
 	}
 
-	tags, cats := splitTagsAndCategories(item.Categories)
-	aliases := []string{aliasPath}
-
-	fm := FrontMatter{
-		Title:      strings.TrimSpace(item.Title),
-		Date:       postTime,
-		Draft:      false,
-		Tags:       tags,
-		Aliases:    aliases,
-		Categories: cats,
+	tags, cats := c.splitTagsAndCategories(item.Categories)
+	draft := false
+	if c.opts.DraftCategory != "" {
+		cats, draft = removeCategory(cats, c.opts.DraftCategory)
 	}
-
-	if err := writeMarkdownFile(slug, fm, bodyMD); err != nil {
-		return err
+	if wxrDraft(item.Status) || c.opts.AllDrafts {
+		draft = true
 	}
-
-	if *verbose {
-		log.Printf("✓ %s -> %s.md (%d chars)", item.Title, slug, len(bodyMD))
+	sectionDir := ""
+	var indexes []string
+	switch c.opts.CategoryHierarchy {
+	case "path":
+		cats = categoryPathTerms(cats, item.CategoryPaths)
+	case "section":
+		if section := categorySection(cats, item.CategoryPaths); section != nil {
+			if sectionDir, indexes, err = c.ensureSectionIndexes(c.opts.Out, section); err != nil {
+				return nil, "", FrontMatter{}, "", fmt.Errorf("section index: %w", err)
+			}
+		}
 	}
-	return nil
-}
-
-func writeMarkdownFile(slug string, fm FrontMatter, body string) error {
-	data, err := yaml.Marshal(&fm)
-	if err != nil {
-		return err
+	route := c.sectionRouteFor(cats)
+	if route != nil {
+		routeIdx, err := c.ensureRouteIndexes(filepath.Join(c.opts.Out, ".."), *route)
+		if err != nil {
+			return nil, "", FrontMatter{}, "", fmt.Errorf("section index: %w", err)
+		}
+		indexes = append(indexes, routeIdx...)
+	}
+
+	// Posts sharing a slug get -2, -3, ... so neither their files nor their
+	// media folders overwrite each other
+	var slug string
+	baseTail := slugTail
+	for n := 1; ; n++ {
+		if n > 1 {
+			slugTail = fmt.Sprintf("%s-%d", baseTail, n)
+		}
+		slug = fmt.Sprintf("%s-%s-%s", year, month, slugTail)
+		outName = slug
+		if c.slugFormatTmpl != nil {
+			outName, err = formatSlug(c.slugFormatTmpl, slugFormatData{
+				Year: year, Month: month, Day: permalinkDay(u.Path, postTime), Slug: slugTail,
+			})
+			if err != nil {
+				return nil, "", FrontMatter{}, "", fmt.Errorf("slug format: %w", err)
+			}
+		}
+		if sectionDir != "" {
+			outName = path.Join(sectionDir, outName)
+		}
+		if route != nil {
+			// Sections sit next to -out (content/posts → content/tutorials)
+			outName = path.Join("..", route.dir, outName)
+		}
+		if c.postNames.claim("media/"+slug, outName) {
+			break
+		}
+	}
+	if slugTail != baseTail {
+		c.warnf("slug %s already used, writing %s as %s", baseTail, item.Link, slugTail)
+	}
+
+	if c.opts.PrettifyHTML {
+		if pretty, err := prettifyHTML(contentHTML); err == nil {
+			contentHTML = pretty
+		} else {
+			c.warnf("prettify %s: %v", slug, err)
+		}
+	}
+
+	// Media go to static/media/<slug>, or into the post's bundle folder
+	mediaName := slug
+	if c.opts.Bundles {
+		mediaName = outName
+	}
+	title := normalizeTitle(item.Title, c.opts.TitleCase)
+	rec = &postRecord{ID: itemID(item), Title: title, Link: item.Link}
+	// audio and video enclosures become players at the end of the body
+	processedHTML, err := c.rewriteAndDownloadImages(contentHTML+enclosureHTML(item.Enclosures, contentHTML), itemBase(item), mediaName, dl, rec)
+	if err != nil {
+		return nil, "", FrontMatter{}, "", fmt.Errorf("rewrite images: %w", err)
+	}
+
+	// -content-format html keeps the (localized) HTML as the page body
+	body = processedHTML
+	if c.opts.ContentFormat == "md" {
+		body, err = c.toMarkdownPreserveOrder(processedHTML, slug)
+		if err != nil {
+			return nil, "", FrontMatter{}, "", fmt.Errorf("html->md: %w", err)
+		}
+	}
+	body = c.attachEnclosures(body, item.Enclosures, contentHTML, mediaName, dl, rec)
+
+	aliases, err := buildAliases([]string{aliasPath}, c.aliasTemplates, aliasData{
+		Year: year, Month: month, Day: permalinkDay(u.Path, postTime),
+		Slug: slug, Name: slugTail, Path: aliasPath,
+	})
+	if err != nil {
+		return nil, "", FrontMatter{}, "", fmt.Errorf("alias template: %w", err)
+	}
+	aliases = c.aliasVariants(aliases)
+	if aliases, err = c.claimAliases(aliases, outName); err != nil {
+		return nil, "", FrontMatter{}, "", err
+	}
+
+	fm = FrontMatter{
+		Title:      title,
+		Slug:       slugTail,
+		Date:       postTime,
+		LastMod:    itemLastMod(item, loc),
+		Draft:      draft,
+		Author:     strings.TrimSpace(item.Creator),
+		Summary:    c.itemSummary(item, contentHTML, body),
+		Tags:       tags,
+		Aliases:    aliases,
+		Categories: cats,
+		Source:     item.SourceName,
+		Kind:       c.formatKind(postFormat(item.Categories)),
+	}
+	if c.opts.Canonical {
+		fm.Canonical = strings.TrimSpace(item.Link)
+	}
+	if c.opts.ReadingTime {
+		fm.WordCount = wordCount(body, c.opts.ContentFormat == "html", c.opts.ReadingTimeSkipCode)
+		fm.ReadingTime = readingTime(fm.WordCount)
+	}
+	fm.Cover = c.postCover(item, mediaName, dl, rec)
+	if c.thumbSize.w > 0 || c.thumbSize.h > 0 {
+		if thumb := c.makeThumbnails(dl, rec); thumb != "" {
+			fm.Thumbnail = path.Join(c.mediaURL(mediaName), filepath.Base(thumb))
+		}
+	}
+	if c.fmTemplate != nil {
+		extra, err := execFMTemplate(c.fmTemplate, fmTemplateData{
+			Item: item, Slug: slug, Title: fm.Title, Date: fm.Date, Tags: tags, Categories: cats,
+		})
+		if err != nil {
+			return nil, "", FrontMatter{}, "", fmt.Errorf("frontmatter template: %w", err)
+		}
+		fm.Extra = extra
+	}
+
+	rec.Date = postTime
+	rec.Tags = tags
+	rec.Categories = cats
+	rec.Aliases = aliases
+	rec.Shortlink = c.shortlink(item.GUID)
+	rec.Indexes = indexes
+	return rec, outName, fm, body, nil
+}
+
+var utf8BOM = []byte("\uFEFF")
+
+// markdownPath is the file writeMarkdownFile writes for name.
+func (c *Converter) markdownPath(name string) string {
+	if c.opts.Bundles {
+		name = path.Join(name, "index") // leaf bundle: <name>/index.md next to its media
+	}
+	return filepath.Join(c.opts.Out, filepath.FromSlash(name)+"."+c.opts.ContentFormat)
+}
+
+// writeMarkdownFile writes <out>/<name>.md, or <out>/<name>/index.md with
+// -bundles (.html with -content-format html); name may contain a section
+// sub-directory. With -overwrite=false an existing file is left as it is.
+func (c *Converter) writeMarkdownFile(name string, fm FrontMatter, body string) (string, error) {
+	// Stray BOMs in the YAML break Hugo's front matter parser
+	fm.Title = strings.TrimSpace(strings.ReplaceAll(fm.Title, "\uFEFF", ""))
+	data, err := c.marshalFrontMatter(fm.toMap(c.opts))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if c.opts.OutputBOM {
+		buf.Write(utf8BOM)
 	}
-	var buf bytes.Buffer
-	buf.WriteString("---\n")
 	buf.Write(data)
-	buf.WriteString("---\n")
-	buf.WriteString(strings.TrimSpace(body))
+	buf.WriteString(strings.TrimSpace(strings.TrimPrefix(body, "\uFEFF")))
 	buf.WriteString("\n")
 
-	outPath := filepath.Join(*outDir, slug+".md")
+	outPath := c.markdownPath(name)
+	if c.stdoutPosts != nil {
+		return outPath, c.stdoutPosts.write(buf.Bytes())
+	}
+	if !c.opts.Overwrite && fileExists(outPath) {
+		if c.dryRun != nil {
+			c.infof("dry-run: would preserve %s (exists, -overwrite=false)", outPath)
+		} else {
+			c.infof("preserved %s (exists, -overwrite=false)", outPath)
+		}
+		return outPath, nil
+	}
+	if c.dryRun != nil {
+		c.infof("dry-run: would write %s (%d bytes)", outPath, buf.Len())
+		c.dryRun.addPost(buf.Len())
+		return outPath, nil
+	}
 	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-		return err
+		return "", err
 	}
-	return os.WriteFile(outPath, buf.Bytes(), 0o644)
+	return outPath, os.WriteFile(outPath, buf.Bytes(), 0o644)
 }
 
-func splitTagsAndCategories(cats []Category) (tags []string, categories []string) {
+func (c *Converter) splitTagsAndCategories(cats []Category) (tags []string, categories []string) {
 	mTags := map[string]struct{}{}
 	mCats := map[string]struct{}{}
-	for _, c := range cats {
-		name := strings.TrimSpace(htmlUnescape(c.Value))
+	for _, cat := range cats {
+		name := strings.TrimSpace(htmlUnescape(cat.Value))
 		if name == "" {
 			continue
 		}
-		if strings.EqualFold(name, "Allgemein") {
+		if cat.Domain == "post_format" {
 			continue
 		}
-		if strings.EqualFold(c.Domain, "post_tag") {
+		if strings.EqualFold(cat.Domain, "post_tag") {
 			mTags[name] = struct{}{}
-		} else {
+		} else if c.categoryAllowed(name) {
 			mCats[name] = struct{}{}
 		}
 	}
@@ -429,6 +1321,20 @@ func splitTagsAndCategories(cats []Category) (tags []string, categories []string
 	return
 }
 
+// removeCategory drops name (case-insensitively) from cats and reports whether it was there.
+func removeCategory(cats []string, name string) ([]string, bool) {
+	out := cats[:0:0]
+	found := false
+	for _, c := range cats {
+		if strings.EqualFold(c, strings.TrimSpace(name)) {
+			found = true
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, found
+}
+
 func setToSortedSlice(m map[string]struct{}) []string {
 	s := make([]string, 0, len(m))
 	for k := range m {
@@ -438,9 +1344,12 @@ func setToSortedSlice(m map[string]struct{}) []string {
 	return s
 }
 
+// spaceRunRe matches runs of spaces and tabs.
+var spaceRunRe = regexp.MustCompile(`[ \t]+`)
+
 // Convert HTML to Markdown, preserving paragraph order and text.
-func toMarkdownPreserveOrder(html string, slug string) (string, error) {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+func (c *Converter) toMarkdownPreserveOrder(html string, slug string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(expandCaptionShortcodes(html)))
 	if err != nil {
 		return "", err
 	}
@@ -450,6 +1359,10 @@ func toMarkdownPreserveOrder(html string, slug string) (string, error) {
 	conv.AddRules(md.Rule{
 		Filter: []string{"p"},
 		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			// WordPress auto-embeds: a paragraph holding just a video URL
+			if sc, ok := embedShortcode(selec.Text()); ok {
+				return md.String(sc + "\n\n")
+			}
 			content = strings.TrimSpace(content)
 			if content == "" {
 				return nil
@@ -481,6 +1394,140 @@ func toMarkdownPreserveOrder(html string, slug string) (string, error) {
 		},
 	})
 
+	// Text as it is (like the visible-text fallback below), but with runs of
+	// spaces collapsed so indented HTML doesn't turn into code blocks. Without
+	// it the inline rules (links) would get no content.
+	conv.AddRules(md.Rule{
+		Filter: []string{"#text"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			return md.String(spaceRunRe.ReplaceAllString(selec.Text(), " "))
+		},
+	})
+
+	// Links → [text](href), so -internal-links and -trim-utm have targets to
+	// rewrite; links around block content (or without an href) keep only it
+	conv.AddRules(md.Rule{
+		Filter: []string{"a"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			href := strings.TrimSpace(selec.AttrOr("href", ""))
+			text := strings.TrimSpace(content)
+			if href == "" || text == "" || strings.Contains(text, "\n\n") {
+				return &content
+			}
+			if strings.ContainsAny(href, " \t") {
+				href = "<" + href + ">"
+			}
+			lead := content[:len(content)-len(strings.TrimLeft(content, " \t\n"))]
+			trail := content[len(strings.TrimRight(content, " \t\n")):]
+			return md.String(lead + "[" + text + "](" + href + ")" + trail)
+		},
+	})
+
+	// Captioned images → {{< figure >}}, so the caption survives
+	conv.AddRules(md.Rule{
+		Filter: []string{"figure"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			sc, ok := figureShortcode(selec)
+			if !ok {
+				// Gutenberg embed blocks wrap the bare video URL
+				if sc, ok := embedShortcode(selec.Text()); ok {
+					return md.String(sc + "\n\n")
+				}
+				return &content // like the converter's default: just the children
+			}
+			return md.String(sc + "\n\n")
+		},
+	})
+
+	// YouTube/Vimeo players → {{< youtube >}} / {{< vimeo >}}, other iframes are dropped
+	conv.AddRules(md.Rule{
+		Filter: []string{"iframe"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			sc, ok := embedShortcode(selec.AttrOr("src", ""))
+			if !ok {
+				return nil
+			}
+			return md.String(sc + "\n\n")
+		},
+	})
+
+	// Embedded tweets → {{< tweet >}}, other blockquotes → "> " quotes with
+	// their <cite> as attribution
+	conv.AddRules(md.Rule{
+		Filter: []string{"blockquote"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			if selec.HasClass("twitter-tweet") {
+				if sc, ok := tweetShortcode(selec); ok {
+					return md.String(sc + "\n\n")
+				}
+			}
+			q := quoteMarkdown(selec)
+			if q == "" {
+				return nil
+			}
+			return md.String(q + "\n\n")
+		},
+	})
+
+	// Audio players → a link to the (downloaded) file
+	conv.AddRules(md.Rule{
+		Filter: []string{"audio"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			src := strings.TrimSpace(selec.AttrOr("src", ""))
+			if src == "" {
+				src = strings.TrimSpace(selec.Find("source").First().AttrOr("src", ""))
+			}
+			if src == "" {
+				return nil
+			}
+			return md.String(fmt.Sprintf("[Audio: %s](%s)\n\n", path.Base(src), src))
+		},
+	})
+
+	// Code blocks → fenced blocks with the plugin's language hint. The
+	// converter collapses blank lines and trims trailing spaces, so they are
+	// swapped in only after conversion.
+	var codeBlocks []string
+	conv.AddRules(md.Rule{
+		Filter: []string{"pre"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			codeBlocks = append(codeBlocks, codeBlock(selec))
+			return md.String(codePlaceholder(len(codeBlocks)-1) + "\n\n")
+		},
+	})
+
+	// Tables → GFM pipe tables, or raw HTML (-table-mode)
+	conv.AddRules(md.Rule{
+		Filter: []string{"table"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			t, ok := c.tableMarkdown(selec)
+			if !ok {
+				return nil
+			}
+			return md.String(t + "\n\n")
+		},
+	})
+
+	// Autoplaying loops (converted GIFs) nested in paragraphs/figures stay raw HTML
+	conv.AddRules(md.Rule{
+		Filter: []string{"video"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			if _, ok := selec.Attr("autoplay"); !ok {
+				return nil
+			}
+			h, err := goquery.OuterHtml(selec)
+			if err != nil {
+				return nil
+			}
+			return md.String(h + "\n\n")
+		},
+	})
+
+	stripImgLoadingHints(doc)
+	if c.opts.FlattenSingleItemLists {
+		flattenSingleItemLists(doc)
+	}
+
 	var b strings.Builder
 	var roots *goquery.Selection
 	if doc.Find("body").Length() > 0 {
@@ -496,13 +1543,26 @@ func toMarkdownPreserveOrder(html string, slug string) (string, error) {
 				return
 			}
 			// Emit text as a paragraph
+			if sc, ok := embedShortcode(s.Text()); ok {
+				b.WriteString(sc + "\n\n")
+				return
+			}
 			b.WriteString(strings.TrimSpace(s.Text()))
 			b.WriteString("\n\n")
 			return
 		}
 
-		// Special handling: Gutenberg gallery block → do not emit inline markup; handled by Hugo convention externally
+		// Images beyond -content-max-images → one gallery shortcode at the end
+		if s.Is("div." + overflowGalleryClass) {
+			c.writeGallery(&b, s)
+			return
+		}
+		// Special handling: Gutenberg gallery block → do not emit inline markup; handled by Hugo convention externally,
+		// unless -gallery-shortcode says how the theme wants it
 		if s.Is(".wp-block-gallery, figure.wp-block-gallery") {
+			if c.galleryTemplate != nil {
+				c.writeGallery(&b, s)
+			}
 			return
 		}
 		// Special handling: Gutenberg video block or plain <video>
@@ -514,6 +1574,13 @@ func toMarkdownPreserveOrder(html string, slug string) (string, error) {
 				vs = s.Find("video").First()
 			}
 			if vs.Length() > 0 {
+				// Autoplaying loops (converted GIFs) only make sense as inline video
+				if _, ok := vs.Attr("autoplay"); ok {
+					if h, err := goquery.OuterHtml(vs); err == nil {
+						b.WriteString(h + "\n\n")
+					}
+					return
+				}
 				src, _ := vs.Attr("src")
 				if strings.TrimSpace(src) == "" {
 					if vv := vs.Find("source").First(); vv.Length() > 0 {
@@ -545,36 +1612,104 @@ func toMarkdownPreserveOrder(html string, slug string) (string, error) {
 			}
 			return
 		}
-		b.WriteString(frag)
-		// Ensure a trailing newline if the fragment didn't add one
-		if !strings.HasSuffix(frag, "\n") {
-			b.WriteString("\n")
-		}
+		// A blank line after each block, or the next paragraph continues it
+		// (as a quote line, a table row or part of an HTML block)
+		b.WriteString(strings.TrimRight(frag, "\n"))
+		b.WriteString("\n\n")
 	})
 
-	return strings.TrimSpace(b.String()), nil
+	out := strings.TrimSpace(b.String())
+	for i, code := range codeBlocks {
+		out = strings.Replace(out, codePlaceholder(i), code, 1)
+	}
+	return out, nil
+}
+
+// flattenSingleItemLists turns top-level lists with a single, non-nested item
+// into a plain paragraph (WordPress editors sometimes produce these by accident).
+func flattenSingleItemLists(doc *goquery.Document) {
+	doc.Find("ul, ol").Each(func(_ int, list *goquery.Selection) {
+		items := list.ChildrenFiltered("li")
+		if items.Length() != 1 || items.Find("ul, ol").Length() > 0 || list.ParentsFiltered("li").Length() > 0 {
+			return
+		}
+		inner, err := items.Html()
+		if err != nil {
+			return
+		}
+		if items.ChildrenFiltered("p, div, figure, blockquote").Length() == 0 {
+			inner = "<p>" + inner + "</p>"
+		}
+		list.ReplaceWithHtml(inner)
+	})
 }
 
+// parsePubDate parses the RSS date formats, ISO 8601 with and without a zone,
+// plain dates and Unix timestamps. Dates without a zone are read in loc.
 func parsePubDate(p string, loc *time.Location) (time.Time, error) {
 	p = strings.TrimSpace(p)
 	if p == "" {
 		return time.Time{}, errors.New("empty pubDate")
 	}
+	if secs, err := strconv.ParseInt(p, 10, 64); err == nil {
+		return time.Unix(secs, 0).In(loc), nil
+	}
 	// Try common RSS formats
-	formats := []string{time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822, time.RFC3339}
-	var t time.Time
-	var err error
+	formats := []string{time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822, time.RFC3339,
+		"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"}
 	for _, f := range formats {
-		t, err = time.Parse(f, p)
-		if err == nil {
-			return t.In(loc), nil
+		if t, err := time.Parse(f, p); err == nil {
+			return rfc822Zone(t).In(loc), nil
+		}
+	}
+	for _, f := range []string{time.DateTime, "2006-01-02T15:04:05", time.DateOnly} {
+		if t, err := time.ParseInLocation(f, p, loc); err == nil {
+			return t, nil
 		}
 	}
 	return time.Time{}, fmt.Errorf("unknown date format: %q", p)
 }
 
-func pubDateYearMonth(p string, loc *time.Location) (string, string) {
-	t, err := parsePubDate(p, loc)
+// rfc822Zones are the named zones RFC 822 allows in dates. time.Parse only
+// knows the local zone's abbreviations and reads the others as UTC.
+var rfc822Zones = map[string]int{
+	"EST": -5, "EDT": -4, "CST": -6, "CDT": -5, "MST": -7, "MDT": -6, "PST": -8, "PDT": -7,
+}
+
+func rfc822Zone(t time.Time) time.Time {
+	name, offset := t.Zone()
+	if h, ok := rfc822Zones[name]; ok && offset != h*3600 {
+		y, mo, d := t.Date()
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, h*3600))
+	}
+	return t
+}
+
+// contentTime returns the first parseable <time datetime="..."> in the body
+// (RFC 3339, or a plain YYYY-MM-DD date taken as midnight in loc).
+func contentTime(html string, loc *time.Location) (time.Time, bool) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var found time.Time
+	doc.Find("time[datetime]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		v := strings.TrimSpace(s.AttrOr("datetime", ""))
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			found = t.In(loc)
+			return false
+		}
+		if t, err := time.ParseInLocation("2006-01-02", v, loc); err == nil {
+			found = t
+			return false
+		}
+		return true
+	})
+	return found, !found.IsZero()
+}
+
+func itemYearMonth(item Item, loc *time.Location) (string, string) {
+	t, err := itemTime(item, loc)
 	if err != nil {
 		now := time.Now().In(loc)
 		return fmt.Sprintf("%04d", now.Year()), fmt.Sprintf("%02d", int(now.Month()))
@@ -593,6 +1728,24 @@ func extractPathParts(p string) (year, month, tail string) {
 	return "", "", ""
 }
 
+// postNameSlug sanitizes a wp:post_name, which WordPress stores percent-encoded for non-ASCII titles.
+func (c *Converter) postNameSlug(name string) string {
+	name = strings.TrimSpace(name)
+	if dec, err := url.PathUnescape(name); err == nil {
+		name = dec
+	}
+	return c.slugify(name)
+}
+
+// permalinkDay is the day segment of a /YYYY/MM/DD/name/ permalink, or the
+// post date's day for other link structures.
+func permalinkDay(p string, postTime time.Time) string {
+	if segs := strings.Split(strings.Trim(p, "/"), "/"); len(segs) >= 4 {
+		return segs[2]
+	}
+	return fmt.Sprintf("%02d", postTime.Day())
+}
+
 func ensureTrailingSlash(p string) string {
 	if p == "" {
 		return "/"
@@ -605,13 +1758,25 @@ func ensureTrailingSlash(p string) string {
 
 var slugRe = regexp.MustCompile(`[^a-z0-9\-]+`)
 
-func replaceEmojisWithCode(s string) string {
+var multiDashRe = regexp.MustCompile(`-{2,}`)
+
+// replaceEmojis applies the -emoji-slug mode: "code" turns emoji into
+// u1F642-style tokens, "name" into keywords from emojiNames (code for unknown
+// ones), "drop" removes them.
+func replaceEmojis(s, mode string) string {
 	var b strings.Builder
 	b.Grow(len(s))
 	for _, r := range s {
 		if isEmojiRune(r) {
-			b.WriteString("u")
-			b.WriteString(strings.ToUpper(fmt.Sprintf("%X", r)))
+			switch {
+			case mode == "drop":
+				b.WriteString(" ")
+			case mode == "name" && emojiNames[r] != "":
+				b.WriteString(" " + emojiNames[r] + " ")
+			default:
+				b.WriteString("u")
+				b.WriteString(strings.ToUpper(fmt.Sprintf("%X", r)))
+			}
 		} else if r == '\u200D' || r == '\uFE0F' { // ZWJ / variation selector – drop
 			continue
 		} else {
@@ -637,29 +1802,70 @@ func isEmojiRune(r rune) bool {
 	return false
 }
 
-func slugify(s string) string {
-	s = replaceEmojisWithCode(s)
-	s = strings.ToLower(s)
+func (c *Converter) slugify(s string) string {
+	s = replaceEmojis(s, c.opts.EmojiSlug)
+	s = strings.ToLower(transliterate(s))
 	s = strings.ReplaceAll(s, " ", "-")
 	s = slugRe.ReplaceAllString(s, "-")
+	if c.opts.EmojiSlug != "code" {
+		// keywords and gaps are padded with spaces; "code" keeps its historical slugs
+		s = multiDashRe.ReplaceAllString(s, "-")
+	}
 	s = strings.Trim(s, "-")
 	return s
 }
 
+// htmlUnescape decodes the named and numeric HTML entities that survive the
+// XML decoding of double-encoded feeds (&raquo;, &mdash;, &#8217;) and turns
+// non-breaking spaces into plain ones. It decodes one level: &amp;amp; becomes
+// &amp;.
 func htmlUnescape(s string) string {
-	// Minimal replacement; XML decoder already unescapes most values
-	return strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.ReplaceAll(html.UnescapeString(s), "\u00a0", " ")
 }
 
-func rewriteAndDownloadImages(html string, slug string, dl *downloader) (string, error) {
+// rewriteAndDownloadImages downloads the post's images and videos into the
+// folder of mediaName (see mediaDir) and points the markup at the local copies.
+// Relative URLs resolve against base, the item's link (nil leaves them as is).
+func (c *Converter) rewriteAndDownloadImages(html string, base *url.URL, mediaName string, dl *downloader, rec *postRecord) (string, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
 		return "", err
 	}
 
+	stripBlockComments(doc)
+	resolveNoscriptImages(doc)
+	resolveLazyImages(doc)
+	resolveRelativeURLs(doc, base)
+	if c.opts.TrimUTM {
+		doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			a.SetAttr("href", c.trimTrackingParams(a.AttrOr("href", "")))
+		})
+	}
+
 	// Per-post image numbering (001_, 002_, ...), based on first mention order
 	imageIndex := 1
 	assigned := make(map[string]int) // original URL -> assigned index
+	inline := 0                      // images kept in the text, see -content-max-images
+	names := newFileNamer()          // for -keep-original-filenames
+	var overflow *goquery.Selection
+
+	// mediaFile is where the image origURL is saved: numbered by first
+	// mention, or by its own name with -keep-original-filenames/-flatten-images
+	mediaFile := func(origURL string) string {
+		num, ok := assigned[origURL]
+		if !ok {
+			num = imageIndex
+			assigned[origURL] = num
+			imageIndex++
+		}
+		filename := fmt.Sprintf("%03d_", num) + filenameFromURL(origURL)
+		if c.opts.KeepOriginalFilenames {
+			filename = names.name(origURL)
+		} else if c.flatMedia != nil {
+			filename = filenameFromURL(origURL) // post numbers mean nothing in a shared folder
+		}
+		return c.flatMedia.dest(origURL, filepath.Join(c.mediaDir(mediaName), filename))
+	}
 
 	doc.Find("img").Each(func(i int, s *goquery.Selection) {
 		// 1) Emojis aus s.w.org / wp-smiley direkt als Unicode einsetzen
@@ -681,27 +1887,38 @@ func rewriteAndDownloadImages(html string, slug string, dl *downloader) (string,
 		}
 
 		// 2) Auf Originaldatei ohne -WxH / -scaled verweisen
-		origURL := toOriginalURL(best)
-
-		base := filepath.Join(*staticDir, "media", slug)
-		relBase := filepath.ToSlash(path.Join("/media", slug))
-		_ = os.MkdirAll(base, 0o755)
+		origURL := c.toOriginalURL(best)
 
-		// Assign stable, per-post index for this original URL based on first mention
-		num, ok := assigned[origURL]
-		if !ok {
-			num = imageIndex
-			assigned[origURL] = num
-			imageIndex++
+		base := c.mediaDir(mediaName)
+		relBase := c.mediaURL(mediaName)
+		if c.dryRun == nil {
+			_ = os.MkdirAll(base, 0o755)
 		}
-		prefix := fmt.Sprintf("%03d_", num)
 
-		filename := prefix + filenameFromURL(origURL)
-		dest := filepath.Join(base, filename)
+		// Assign stable, per-post index for this original URL based on first mention
+		dest := mediaFile(origURL)
+		filename := filepath.Base(dest)
 		rel := path.Join(relBase, filename)
 
+		// Animated GIFs → looping MP4 (fetched right away, the markup depends on the result)
+		if c.opts.GIFToMP4 && strings.EqualFold(path.Ext(filename), ".gif") {
+			if mp4, ok := c.convertAnimatedGIF(dl, origURL, dest); ok {
+				mp4Rel := path.Join(relBase, filepath.Base(mp4))
+				rec.addAsset(origURL, mp4)
+				if a := s.ParentsFiltered("a").First(); a.Length() > 0 {
+					a.SetAttr("href", mp4Rel)
+				}
+				_ = s.ReplaceWithHtml(gifVideoHTML(mp4Rel))
+				return
+			}
+		}
+
 		// 3) Download und Umschreiben der Attribute (src, evtl. a[href])
-		dl.Schedule(origURL, dest)
+		if dest = c.scheduleMedia(dl, origURL, dest, true); dest == "" {
+			return // over -max-image-bytes, the markup keeps the remote image
+		}
+		rel = path.Join(relBase, filepath.Base(dest))
+		rec.addAsset(origURL, dest)
 
 		s.RemoveAttr("srcset")
 		s.RemoveAttr("sizes")
@@ -709,13 +1926,43 @@ func rewriteAndDownloadImages(html string, slug string, dl *downloader) (string,
 
 		// Falls das Bild von einem Link umschlossen ist, den Link ebenfalls lokal machen
 		if a := s.ParentsFiltered("a").First(); a.Length() > 0 {
-			a.SetAttr("href", rel)
+			href := rel
+			// a lightbox link to a different full-size image gets its own copy
+			if full := c.toOriginalURL(a.AttrOr("href", "")); full != origURL && isRemoteImage(full) {
+				if fullDest := c.scheduleMedia(dl, full, mediaFile(full), true); fullDest == "" {
+					href = full // over -max-image-bytes or disallowed, stays remote
+				} else {
+					href = path.Join(relBase, filepath.Base(fullDest))
+					rec.addAsset(full, fullDest)
+				}
+			}
+			a.SetAttr("href", href)
+		}
+
+		// Past -content-max-images, move the image into the overflow gallery
+		if c.opts.ContentMaxImages <= 0 || s.Closest(".wp-block-gallery").Length() > 0 {
+			return
+		}
+		if inline++; inline <= c.opts.ContentMaxImages {
+			return
+		}
+		if overflow == nil {
+			doc.Find("body").AppendHtml(`<div class="` + overflowGalleryClass + `"></div>`)
+			overflow = doc.Find("body > div." + overflowGalleryClass)
 		}
+		wrapper := s.Closest("figure")
+		if wrapper.Length() == 0 && s.Parent().Is("a") && s.Siblings().Length() == 0 {
+			wrapper = s.Parent()
+		}
+		s.RemoveAttr("width")
+		s.RemoveAttr("height")
+		overflow.AppendSelection(s)
+		wrapper.Remove()
 	})
-	// Handle HTML5 videos: download to static/videos/$slug and rewrite src to local path
-	doc.Find("video").Each(func(i int, v *goquery.Selection) {
+	// Handle HTML5 videos and audio: download to the post's media folder and rewrite src to local path
+	doc.Find("video, audio").Each(func(i int, v *goquery.Selection) {
 		src, _ := v.Attr("src")
-		// Some WP videos use <source src> children instead of video@src
+		// Some WP videos and audio players use <source src> children instead of @src
 		if strings.TrimSpace(src) == "" {
 			if vv := v.Find("source").First(); vv.Length() > 0 {
 				src, _ = vv.Attr("src")
@@ -725,23 +1972,31 @@ func rewriteAndDownloadImages(html string, slug string, dl *downloader) (string,
 			return
 		}
 
-		base := filepath.Join(*staticDir, "media", slug)
-		relBase := filepath.ToSlash(path.Join("/media", slug))
-		_ = os.MkdirAll(base, 0o755)
+		base := c.mediaDir(mediaName)
+		relBase := c.mediaURL(mediaName)
+		if c.dryRun == nil {
+			_ = os.MkdirAll(base, 0o755)
+		}
 
 		filename := filenameFromURL(src)
 		dest := filepath.Join(base, filename)
 		rel := path.Join(relBase, filename)
 
-		// schedule download of the original video URL (no WP size suffix stripping for videos)
-		dl.Schedule(src, dest)
+		// schedule download of the original URL (no WP size suffix stripping for videos and audio)
+		dest = c.scheduleMedia(dl, src, dest, false)
+		rel = path.Join(relBase, filepath.Base(dest))
+		rec.addAsset(src, dest)
 
-		// rewrite video@src and any <source src> children to the local relative path
+		// rewrite @src and any <source src> children to the local relative path
 		v.SetAttr("src", rel)
 		v.Find("source").Each(func(_ int, s *goquery.Selection) {
 			s.SetAttr("src", rel)
 		})
 	})
+	stripImgLoadingHints(doc)
+	if c.opts.StripAttrs != "" {
+		stripAttributes(doc, strings.Split(c.opts.StripAttrs, ","))
+	}
 	// Serialize modified HTML back to string (inner contents)
 	var outParts []string
 	root := doc.Selection
@@ -764,12 +2019,280 @@ func rewriteAndDownloadImages(html string, slug string, dl *downloader) (string,
 	return strings.TrimSpace(strings.Join(outParts, "")), nil
 }
 
+// scheduleMedia queues the download of rawURL to dest and returns the file's
+// final path. A dest without an extension is fetched right away, since the
+// extension comes from the response and the markup must use the final name.
+// So are images under -max-image-bytes: for an oversized one it returns "",
+// as for an image robots.txt disallows (-respect-robots).
+func (c *Converter) scheduleMedia(dl *downloader, rawURL, dest string, image bool) string {
+	if image && !c.robots.allowed(rawURL) {
+		c.infof("skipped %s (disallowed by robots.txt)", rawURL)
+		return ""
+	}
+	dest = c.flatMedia.dest(rawURL, dest)
+	if filepath.Ext(dest) != "" && !(image && c.opts.MaxImageBytes > 0) {
+		dl.Schedule(rawURL, dest)
+		return dest
+	}
+	err := dl.Fetch(rawURL, dest)
+	if errors.Is(err, errTooLarge) {
+		return ""
+	}
+	if err != nil {
+		c.warnf("download failed %s -> %s: %v", rawURL, dest, err)
+		return dest
+	}
+	if final := dl.DestAt(rawURL, dest); final != "" {
+		return final
+	}
+	return dest
+}
+
+var wpBlockCommentRe = regexp.MustCompile(`^\s*/?wp:`)
+
+// stripBlockComments removes Gutenberg block delimiters (<!-- wp:paragraph -->,
+// <!-- /wp:paragraph -->) while keeping their content and other comments like <!--more-->.
+func stripBlockComments(doc *goquery.Document) {
+	doc.Find("*").AddSelection(doc.Selection).Contents().Each(func(_ int, c *goquery.Selection) {
+		if goquery.NodeName(c) == "#comment" && wpBlockCommentRe.MatchString(c.Nodes[0].Data) {
+			c.Remove()
+		}
+	})
+}
+
+// resolveNoscriptImages handles lazy-load markup, where the visible <img> is a
+// placeholder and the real one sits in a <noscript> fallback next to it: the
+// placeholder takes over the fallback's src/srcset and the <noscript> goes away.
+// A <noscript> image without any visible counterpart is unwrapped instead.
+func resolveNoscriptImages(doc *goquery.Document) {
+	doc.Find("noscript").Each(func(_ int, ns *goquery.Selection) {
+		// With scripting enabled the parser keeps <noscript> contents as raw text
+		inner := ns.Text()
+		if ns.Children().Length() > 0 {
+			inner, _ = ns.Html()
+		}
+		fallback, err := goquery.NewDocumentFromReader(strings.NewReader(inner))
+		if err != nil {
+			return
+		}
+		fb := fallback.Find("img").First()
+		if fb.Length() == 0 {
+			return
+		}
+		visible := ns.PrevFiltered("img")
+		if visible.Length() == 0 {
+			visible = ns.NextFiltered("img")
+		}
+		switch {
+		case visible.Length() == 0:
+			if h, err := goquery.OuterHtml(fb); err == nil {
+				ns.ReplaceWithHtml(h)
+			}
+		case isLazyPlaceholder(visible):
+			for _, attr := range []string{"src", "srcset", "sizes", "alt"} {
+				if v, ok := fb.Attr(attr); ok {
+					visible.SetAttr(attr, v)
+				}
+			}
+			for _, attr := range []string{"data-src", "data-srcset", "data-lazy-src", "data-lazy-srcset", "data-sizes"} {
+				visible.RemoveAttr(attr)
+			}
+			ns.Remove()
+		default:
+			ns.Remove() // fallback for an image that is shown anyway
+		}
+	})
+}
+
+// lazySrcAttrs and lazySrcsetAttrs hold the real image of lazy-loaded <img>s,
+// in order of preference.
+var (
+	lazySrcAttrs    = []string{"data-lazy-src", "data-src", "data-orig-src"}
+	lazySrcsetAttrs = []string{"data-lazy-srcset", "data-srcset"}
+)
+
+// resolveLazyImages moves the real URLs of lazy-loaded images (data-src,
+// data-lazy-src, data-lazy-srcset, data-orig-src, …) into src/srcset, so the
+// image is downloaded instead of its placeholder.
+func resolveLazyImages(doc *goquery.Document) {
+	doc.Find("img").Each(func(_ int, img *goquery.Selection) {
+		for _, attr := range lazySrcAttrs {
+			if v := strings.TrimSpace(img.AttrOr(attr, "")); v != "" && !strings.HasPrefix(v, "data:") {
+				img.SetAttr("src", v)
+				if strings.HasPrefix(strings.TrimSpace(img.AttrOr("srcset", "")), "data:") {
+					img.RemoveAttr("srcset") // the placeholder's
+				}
+				break
+			}
+		}
+		for _, attr := range lazySrcsetAttrs {
+			if v := strings.TrimSpace(img.AttrOr(attr, "")); v != "" {
+				img.SetAttr("srcset", v)
+				break
+			}
+		}
+		for _, attr := range append(append([]string{"data-sizes"}, lazySrcAttrs...), lazySrcsetAttrs...) {
+			img.RemoveAttr(attr)
+		}
+	})
+}
+
+// isLazyPlaceholder reports whether img looks like a lazy-load stand-in
+// (no src, an inline data: URI, or lazy-load attributes/classes).
+func isLazyPlaceholder(img *goquery.Selection) bool {
+	src := strings.TrimSpace(img.AttrOr("src", ""))
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return true
+	}
+	for _, attr := range []string{"data-src", "data-lazy-src", "data-srcset", "data-lazy-srcset"} {
+		if _, ok := img.Attr(attr); ok {
+			return true
+		}
+	}
+	return strings.Contains(img.AttrOr("class", ""), "lazy")
+}
+
+// imgLoadingHints are browser loading hints on <img> that mean nothing in a
+// static Markdown page.
+var imgLoadingHints = []string{"loading", "decoding", "fetchpriority", "sizes"}
+
+// stripImgLoadingHints removes imgLoadingHints from all images; dimensions stay.
+func stripImgLoadingHints(doc *goquery.Document) {
+	doc.Find("img").Each(func(_ int, img *goquery.Selection) {
+		for _, a := range imgLoadingHints {
+			img.RemoveAttr(a)
+		}
+	})
+}
+
+// keptAttrs are never stripped, they carry the content itself.
+var keptAttrs = map[string]bool{"href": true, "src": true, "alt": true}
+
+// blockClasses are the class tokens toMarkdownPreserveOrder recognizes blocks by;
+// stripping "class" keeps them.
+var blockClasses = []string{"wp-block-gallery", "wp-block-video", overflowGalleryClass}
+
+// overflowGalleryClass marks the container of the images beyond
+// -content-max-images; it is rendered as a gallery shortcode.
+const overflowGalleryClass = "wp2hugo-overflow-gallery"
+
+// stripAttributes removes the named attributes from every element. A trailing
+// "*" matches by prefix ("data-*").
+func stripAttributes(doc *goquery.Document, names []string) {
+	strip := func(key string) bool {
+		if keptAttrs[key] {
+			return false
+		}
+		for _, n := range names {
+			n = strings.ToLower(strings.TrimSpace(n))
+			if p, ok := strings.CutSuffix(n, "*"); (ok && p != "" && strings.HasPrefix(key, p)) || n == key {
+				return true
+			}
+		}
+		return false
+	}
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		var drop []string
+		for _, a := range s.Nodes[0].Attr {
+			if strip(a.Key) {
+				drop = append(drop, a.Key)
+			}
+		}
+		for _, k := range drop {
+			if k == "class" {
+				var keep []string
+				for _, c := range blockClasses {
+					if s.HasClass(c) {
+						keep = append(keep, c)
+					}
+				}
+				if len(keep) > 0 {
+					s.SetAttr("class", strings.Join(keep, " "))
+					continue
+				}
+			}
+			s.RemoveAttr(k)
+		}
+	})
+}
+
+// trimTrackingParams drops the -strip-link-params (utm_*, fbclid and gclid
+// by default) from a link's query; functional parameters stay.
+func (c *Converter) trimTrackingParams(href string) string {
+	return stripParamList(href, c.opts.StripLinkParams)
+}
+
+// isRemoteImage reports whether raw is an http(s) URL of an image file, by
+// its extension.
+func isRemoteImage(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return coverExts[strings.ToLower(path.Ext(u.Path))]
+}
+
+// stripMediaParams drops the -strip-media-params from an image URL, so the
+// same file under several cache-busting or tracking query strings is
+// downloaded once.
+func (c *Converter) stripMediaParams(raw string) string {
+	return stripParamList(raw, c.opts.StripMediaParams)
+}
+
+// stripParamList removes the query parameters named in list, comma-separated
+// and case-insensitive, with a '*' suffix for prefixes.
+func stripParamList(raw, list string) string {
+	var names []string
+	for _, n := range strings.Split(list, ",") {
+		if n = strings.ToLower(strings.TrimSpace(n)); n != "" {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return raw
+	}
+	return stripQueryParams(raw, func(key string) bool {
+		for _, n := range names {
+			if p, ok := strings.CutSuffix(n, "*"); (ok && p != "" && strings.HasPrefix(key, p)) || n == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// stripQueryParams removes the query parameters whose (lowercased) name drop
+// matches, keeping the others in their original order. A query left empty
+// loses its "?".
+func stripQueryParams(href string, drop func(key string) bool) string {
+	u, err := url.Parse(href)
+	if err != nil || u.RawQuery == "" {
+		return href
+	}
+	var keep []string
+	for _, p := range strings.Split(u.RawQuery, "&") {
+		key, _, _ := strings.Cut(p, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if drop(strings.ToLower(key)) {
+			continue
+		}
+		keep = append(keep, p)
+	}
+	u.RawQuery = strings.Join(keep, "&")
+	u.ForceQuery = false
+	return u.String()
+}
+
 var srcsetRe = regexp.MustCompile(`,?\s*([^\s,]+)\s+(\d+)w`)
 var wpSizeSuffixRe = regexp.MustCompile(`-(?:\d+)x(?:\d+)(?:-[0-9]+)?$`)
 var wpScaledSuffixRe = regexp.MustCompile(`-scaled(?:-[0-9]+)?$`)
 
-func toOriginalURL(raw string) string {
-	u, err := url.Parse(raw)
+// toOriginalURL points an image URL at the full-size original (no -WxH or
+// -scaled suffix) without the -strip-media-params.
+func (c *Converter) toOriginalURL(raw string) string {
+	u, err := url.Parse(c.stripMediaParams(raw))
 	if err != nil {
 		return raw
 	}
@@ -806,6 +2329,41 @@ func pickBestSrc(src string, srcset string) string {
 	return best
 }
 
+var safeFilenameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+var unsafeFilenameCharsRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileNamer picks the file names of one post's media folder for
+// -keep-original-filenames: the URL's base name verbatim when it is
+// filesystem-safe and not taken by another URL, otherwise a sanitized name
+// with a short hash of the URL.
+type fileNamer struct {
+	used map[string]string // file name -> URL
+}
+
+func newFileNamer() *fileNamer {
+	return &fileNamer{used: make(map[string]string)}
+}
+
+func (n *fileNamer) name(rawURL string) string {
+	base := filenameFromURL(rawURL)
+	if owner, taken := n.used[base]; safeFilenameRe.MatchString(base) && (!taken || owner == rawURL) {
+		n.used[base] = rawURL
+		return base
+	}
+	ext := path.Ext(base)
+	if !safeFilenameRe.MatchString("x" + ext) {
+		ext = ""
+	}
+	stem := strings.Trim(unsafeFilenameCharsRe.ReplaceAllString(strings.TrimSuffix(base, path.Ext(base)), "-"), "-.")
+	if stem == "" {
+		stem = "file"
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	name := fmt.Sprintf("%s-%x%s", stem, sum[:4], ext)
+	n.used[name] = rawURL
+	return name
+}
+
 func filenameFromURL(raw string) string {
 	u, err := url.Parse(raw)
 	if err != nil {
@@ -821,15 +2379,29 @@ func filenameFromURL(raw string) string {
 // Downloader implements deduplicated concurrent downloads
 
 type downloader struct {
+	c       *Converter
 	wg      sync.WaitGroup
 	sem     chan struct{}
-	seen    sync.Map // url -> struct{}
+	seen    sync.Map // url -> chan struct{}, closed once the download finished
+	results sync.Map // url -> dlResult, once the download finished
 	hostSem map[string]chan struct{}
-	mu      sync.Mutex
+	mu      sync.Mutex // guards hostSem, failed and byHash
 	perHost int
+	failed  []dlFailure
+	byHash  map[string][]string // sha256 -> dests, for -dedupe-media
+	limiter *rateLimiter        // -rate; nil = unlimited
+	delay   time.Duration       // -delay after each download
+
+	progress dlProgress
 }
 
-func newDownloader(concurrency int, perhost int) *downloader {
+// dlFailure is a download that failed for good (after all retries).
+type dlFailure struct {
+	URL, Dest string
+	Err       error
+}
+
+func (c *Converter) newDownloader(concurrency int, perhost int) *downloader {
 	if concurrency < 1 {
 		concurrency = 1
 	}
@@ -837,6 +2409,7 @@ func newDownloader(concurrency int, perhost int) *downloader {
 		perhost = 1
 	}
 	return &downloader{
+		c:       c,
 		sem:     make(chan struct{}, concurrency),
 		hostSem: make(map[string]chan struct{}),
 		perHost: perhost,
@@ -855,7 +2428,25 @@ func (d *downloader) getHostSem(host string) chan struct{} {
 }
 
 func (d *downloader) Schedule(rawURL string, dest string) {
-	if _, exists := d.seen.LoadOrStore(rawURL, struct{}{}); exists {
+	done := make(chan struct{})
+	if v, exists := d.seen.LoadOrStore(rawURL, done); exists {
+		d.scheduleCopy(rawURL, dest, v.(chan struct{}))
+		return
+	}
+	if d.c.dryRun != nil {
+		d.pretend(rawURL, dest)
+		close(done)
+		return
+	}
+	d.progress.scheduled.Add(1)
+	if existing, ok := existingMedia(dest); d.c.opts.SkipExisting && ok {
+		// only hash the file from an earlier run, without a worker slot
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.reuse(rawURL, existing)
+			close(done)
+		}()
 		return
 	}
 	host := ""
@@ -876,49 +2467,314 @@ func (d *downloader) Schedule(rawURL string, dest string) {
 			hsem <- struct{}{}
 			defer func() { <-hsem }()
 		}
-		if err := downloadFile(rawURL, dest); err != nil {
-			log.Printf("download failed %s -> %s: %v", rawURL, dest, err)
-		} else if *verbose {
-			log.Printf("downloaded %s", dest)
+		d.limiter.Wait() // holding the slots, so -rate and -concurrency both apply
+		start := time.Now()
+		dest, sum, err := d.c.downloadFile(rawURL, dest)
+		d.c.timings.addDownload(time.Since(start))
+		d.store(rawURL, dlResult{err: err, dest: dest, sha256: sum})
+		close(done)
+		if err != nil && !errors.Is(err, errTooLarge) {
+			d.c.warnf("download failed %s -> %s: %v", rawURL, dest, err)
+		} else if err == nil {
+			d.c.debugf("downloaded %s", dest)
 		}
+		time.Sleep(d.delay) // still holding the slots, so the next download waits
 	}()
 }
 
-func (d *downloader) Wait() { d.wg.Wait() }
+type dlResult struct {
+	err    error
+	dest   string
+	sha256 string // hex digest of the file, set on success
+}
+
+// Result reports whether the download of rawURL has finished and with which error.
+func (d *downloader) Result(rawURL string) (done bool, err error) {
+	v, ok := d.results.Load(rawURL)
+	if !ok {
+		return false, nil
+	}
+	return true, v.(dlResult).err
+}
 
-func downloadFile(rawURL, dest string) error {
-	// Skip if file already exists and is non-empty
-	if st, err := os.Stat(dest); err == nil && st.Size() > 0 {
+// Fetch downloads rawURL synchronously, for callers that need the file right away.
+// If the URL is already scheduled, it waits for that download instead and
+// copies the file to dest.
+func (d *downloader) Fetch(rawURL, dest string) error {
+	done := make(chan struct{})
+	if v, exists := d.seen.LoadOrStore(rawURL, done); exists {
+		<-v.(chan struct{})
+		key := copyKey(rawURL, dest)
+		copied := make(chan struct{})
+		if c, exists := d.seen.LoadOrStore(key, copied); exists {
+			<-c.(chan struct{})
+		} else {
+			d.copyDownload(rawURL, dest)
+			close(copied)
+		}
+		return d.result(key).err
+	}
+	if d.c.dryRun != nil {
+		d.pretend(rawURL, dest)
+		close(done)
 		return nil
 	}
+	d.progress.scheduled.Add(1)
+	if existing, ok := existingMedia(dest); d.c.opts.SkipExisting && ok {
+		err := d.reuse(rawURL, existing)
+		close(done)
+		return err
+	}
+	d.limiter.Wait()
+	dest, sum, err := d.c.downloadFile(rawURL, dest)
+	d.store(rawURL, dlResult{err: err, dest: dest, sha256: sum})
+	close(done)
+	time.Sleep(d.delay)
+	return err
+}
+
+func (d *downloader) Wait() { d.wg.Wait() }
+
+// A URL used by several posts is downloaded once; every other dest it is
+// scheduled for gets a copy of the file once that download finished. The
+// copies' results are kept under copyKey, so each post's media are tracked
+// on their own (see ResultAt).
+
+func copyKey(rawURL, dest string) string { return rawURL + "\x00" + dest }
+
+// scheduleCopy copies rawURL's file to dest once done is closed.
+func (d *downloader) scheduleCopy(rawURL, dest string, done chan struct{}) {
+	if d.c.dryRun != nil {
+		return
+	}
+	copied := make(chan struct{})
+	if _, exists := d.seen.LoadOrStore(copyKey(rawURL, dest), copied); exists {
+		return
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		<-done
+		d.copyDownload(rawURL, dest)
+		close(copied)
+	}()
+}
+
+// copyDownload copies the finished download of rawURL to dest, which gets the
+// downloaded file's extension when it has none, and records the outcome for
+// dest. A failed download, or one already saved at dest, is recorded as is.
+func (d *downloader) copyDownload(rawURL, dest string) {
+	key := copyKey(rawURL, dest)
+	res := d.result(rawURL)
+	if filepath.Ext(dest) == "" {
+		dest += filepath.Ext(res.dest)
+	}
+	if res.err != nil || res.dest == dest || d.c.dryRun != nil {
+		d.results.Store(key, res)
+		return
+	}
+	sum, err := copyFile(res.dest, dest)
+	copied := dlResult{err: err, dest: dest, sha256: sum}
+	d.results.Store(key, copied)
+	d.results.Store(copyKey(rawURL, dest), copied)
+	if err != nil {
+		d.c.warnf("copy %s -> %s: %v", rawURL, dest, err)
+		d.mu.Lock()
+		d.failed = append(d.failed, dlFailure{URL: rawURL, Dest: dest, Err: err})
+		d.mu.Unlock()
+		return
+	}
+	if d.c.opts.DedupeMedia {
+		d.mu.Lock()
+		d.indexHash(dest, sum)
+		d.mu.Unlock()
+	}
+	d.c.debugf("copied %s -> %s", res.dest, dest)
+}
+
+// result is the recorded outcome under key, a URL or a copyKey.
+func (d *downloader) result(key string) dlResult {
+	v, _ := d.results.Load(key)
+	r, _ := v.(dlResult)
+	return r
+}
+
+// ResultAt is Result for the copy of rawURL at dest: a URL shared by several
+// posts is done for dest only once dest has its file.
+func (d *downloader) ResultAt(rawURL, dest string) (done bool, err error) {
+	if _, copying := d.seen.Load(copyKey(rawURL, dest)); copying {
+		v, ok := d.results.Load(copyKey(rawURL, dest))
+		if !ok {
+			return false, nil
+		}
+		return true, v.(dlResult).err
+	}
+	return d.Result(rawURL)
+}
+
+// store records the outcome of rawURL's download and keeps failures for
+// Failures. Files skipped for -max-image-bytes are logged, not failures.
+func (d *downloader) store(rawURL string, res dlResult) {
+	d.results.Store(rawURL, res)
+	d.progress.completed.Add(1)
+	if res.err == nil {
+		d.progress.succeeded.Add(1)
+		if d.c.opts.DedupeMedia && res.sha256 != "" {
+			d.mu.Lock()
+			d.indexHash(res.dest, res.sha256)
+			d.mu.Unlock()
+		}
+		return
+	}
+	if errors.Is(res.err, errTooLarge) {
+		d.c.infof("skipped %s: %v", rawURL, res.err)
+		return
+	}
+	d.mu.Lock()
+	d.failed = append(d.failed, dlFailure{URL: rawURL, Dest: res.dest, Err: res.err})
+	d.mu.Unlock()
+}
+
+// Failures returns the failed downloads sorted by URL. Call it after Wait.
+func (d *downloader) Failures() []dlFailure {
+	d.mu.Lock()
+	out := append([]dlFailure(nil), d.failed...)
+	d.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+// pretend records rawURL as downloaded to dest without fetching it (-dry-run).
+func (d *downloader) pretend(rawURL, dest string) {
+	d.c.infof("dry-run: would download %s -> %s", rawURL, dest)
+	d.c.dryRun.addMedia()
+	d.results.Store(rawURL, dlResult{dest: dest})
+}
+
+// reuse records the existing dest (-skip-existing) as the download of rawURL.
+func (d *downloader) reuse(rawURL, dest string) error {
+	sum, err := fileSHA256(dest)
+	if err == nil {
+		d.progress.reused.Add(1)
+	}
+	d.store(rawURL, dlResult{err: err, dest: dest, sha256: sum})
+	if err != nil {
+		d.c.warnf("read existing %s: %v", dest, err)
+	} else {
+		d.c.debugf("skipped %s (exists)", dest)
+	}
+	return err
+}
+
+// Dest returns where rawURL was saved, which may have gained an extension
+// from the Content-Type (see downloadFile). Empty until the download finished.
+func (d *downloader) Dest(rawURL string) string {
+	v, ok := d.results.Load(rawURL)
+	if !ok {
+		return ""
+	}
+	return v.(dlResult).dest
+}
+
+// DestAt is Dest for the copy of rawURL at dest (see ResultAt).
+func (d *downloader) DestAt(rawURL, dest string) string {
+	if v, ok := d.results.Load(copyKey(rawURL, dest)); ok {
+		return v.(dlResult).dest
+	}
+	return d.Dest(rawURL)
+}
+
+func nonEmptyFile(p string) bool {
+	st, err := os.Stat(p)
+	return err == nil && st.Mode().IsRegular() && st.Size() > 0
+}
+
+// existingMedia finds dest from an earlier run, also under the extension
+// an extensionless dest gets from its Content-Type.
+func existingMedia(dest string) (string, bool) {
+	if nonEmptyFile(dest) {
+		return dest, true
+	}
+	if filepath.Ext(dest) != "" {
+		return "", false
+	}
+	for _, ext := range contentTypeExts {
+		if nonEmptyFile(dest + ext) {
+			return dest + ext, true
+		}
+	}
+	return "", false
+}
+
+// contentTypeExts maps media types to the extension appended to file names
+// that have none (CDN URLs like /abc123?format=jpg).
+var contentTypeExts = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/webp":    ".webp",
+	"image/gif":     ".gif",
+	"image/avif":    ".avif",
+	"image/svg+xml": ".svg",
+	"video/mp4":     ".mp4",
+	"video/webm":    ".webm",
+}
+
+func extFromContentType(ct string) string {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ""
+	}
+	return contentTypeExts[mt]
+}
+
+// errTooLarge is returned for images over -max-image-bytes.
+var errTooLarge = errors.New("larger than -max-image-bytes")
+
+// downloadTimeout is the -download-timeout, else -timeout seconds (at
+// least 10).
+func (c *Converter) downloadTimeout() time.Duration {
+	if c.opts.DownloadTimeout > 0 {
+		return c.opts.DownloadTimeout
+	}
+	return max(time.Duration(c.opts.Timeout)*time.Second, 10*time.Second)
+}
 
-	attempts := *retries
+// downloadFile fetches rawURL into dest and returns the final path and the
+// file's SHA-256 (hex), hashed while streaming to disk. A dest without an
+// extension gets one from the response Content-Type. Images over
+// -max-image-bytes are refused by Content-Length, or mid-copy without one.
+func (c *Converter) downloadFile(rawURL, dest string) (string, string, error) {
+	if cached, sum, ok, err := c.mediaCache.fetch(rawURL, dest); ok {
+		c.debugf("cached %s", cached)
+		return cached, sum, nil
+	} else if err != nil {
+		c.warnf("cache %s: %v", rawURL, err)
+	}
+	attempts := c.opts.Retries
 	if attempts < 1 {
 		attempts = 1
 	}
-	t := time.Duration(*timeoutSec) * time.Second
-	if t < 10*time.Second {
-		t = 10 * time.Second
-	}
+	t := c.downloadTimeout()
 
 	for attempt := 1; attempt <= attempts; attempt++ {
-		transport := &http.Transport{
+		transport := c.withTLSHosts(&http.Transport{
 			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: *perHost,
-			MaxConnsPerHost:     *perHost,
-		}
+			MaxIdleConnsPerHost: c.opts.PerHost,
+			MaxConnsPerHost:     c.opts.PerHost,
+		})
 		client := &http.Client{Timeout: t, Transport: transport}
 
 		req, err := http.NewRequest("GET", rawURL, nil)
 		if err != nil {
-			return err
+			return dest, "", err
 		}
-		req.Header.Set("User-Agent", "wordpress2hugo/1.0 (+https://example.com)")
+		c.addRequestHeaders(req)
 
+		c.globalLimiter.Wait()
 		resp, err := client.Do(req)
 		if err != nil {
 			if attempt == attempts {
-				return err
+				return dest, "", err
 			}
 			// backoff with jitter
 			time.Sleep(time.Duration(attempt*2)*time.Second + time.Duration(rand.Intn(500))*time.Millisecond)
@@ -926,8 +2782,15 @@ func downloadFile(rawURL, dest string) error {
 		}
 
 		var copyErr error
+		var wait time.Duration // server-requested backoff (429 Retry-After)
+		h := sha256.New()
 		func() {
 			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusTooManyRequests {
+				copyErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+				wait = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+				return
+			}
 			if resp.StatusCode >= 500 {
 				copyErr = fmt.Errorf("HTTP %d", resp.StatusCode)
 				return
@@ -938,11 +2801,26 @@ func downloadFile(rawURL, dest string) error {
 				attempt = attempts
 				return
 			}
+			limit := int64(-1)
+			if c.opts.MaxImageBytes > 0 && strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+				limit = c.opts.MaxImageBytes
+			}
+			if limit >= 0 && resp.ContentLength > limit {
+				copyErr = fmt.Errorf("%w (%d bytes)", errTooLarge, resp.ContentLength)
+				attempt = attempts
+				return
+			}
+			if filepath.Ext(dest) == "" {
+				dest += extFromContentType(resp.Header.Get("Content-Type"))
+			}
 			if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
 				copyErr = err
 				return
 			}
-			f, err := os.Create(dest)
+			// Write to a .part file and rename, so an interrupted run never leaves
+			// a truncated file that looks complete on resume.
+			part := dest + ".part"
+			f, err := os.Create(part)
 			if err != nil {
 				copyErr = err
 				return
@@ -950,24 +2828,45 @@ func downloadFile(rawURL, dest string) error {
 			defer func() {
 				f.Close()
 				if copyErr != nil {
-					_ = os.Remove(dest)
+					_ = os.Remove(part)
 				}
 			}()
-			if _, err = io.Copy(f, resp.Body); err != nil {
+			var body io.Reader = resp.Body
+			if limit >= 0 {
+				body = io.LimitReader(resp.Body, limit+1)
+			}
+			n, err := io.Copy(io.MultiWriter(f, h), body)
+			if err != nil {
+				copyErr = err
+				return
+			}
+			if limit >= 0 && n > limit {
+				copyErr = fmt.Errorf("%w (more than %d bytes)", errTooLarge, limit)
+				attempt = attempts
+				return
+			}
+			if err = f.Close(); err != nil {
 				copyErr = err
 				return
 			}
+			copyErr = os.Rename(part, dest)
 		}()
 
 		if copyErr == nil {
-			return nil
+			if err := c.mediaCache.store(rawURL, dest); err != nil {
+				c.warnf("cache %s: %v", rawURL, err)
+			}
+			return dest, hex.EncodeToString(h.Sum(nil)), nil
 		}
 		if attempt == attempts {
-			return copyErr
+			return dest, "", copyErr
+		}
+		if wait <= 0 {
+			wait = time.Duration(attempt*2)*time.Second + time.Duration(rand.Intn(500))*time.Millisecond
 		}
-		time.Sleep(time.Duration(attempt*2)*time.Second + time.Duration(rand.Intn(500))*time.Millisecond)
+		time.Sleep(wait)
 	}
-	return fmt.Errorf("unreachable")
+	return dest, "", fmt.Errorf("unreachable")
 }
 
 func fileExists(p string) bool {
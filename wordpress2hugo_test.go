@@ -0,0 +1,1209 @@
+package wordpress2hugo
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TestMain lets a test run the command itself: with WP2HUGO_RUN_MAIN=1 the
+// test binary behaves like wordpress2hugo (see runMain).
+func TestMain(m *testing.M) {
+	if os.Getenv("WP2HUGO_RUN_MAIN") == "1" {
+		os.Exit(Main(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs wordpress2hugo with args in a child process, so every run
+// starts with fresh flags and registries like a real invocation.
+func runMain(t *testing.T, args ...string) (output string, err error) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "WP2HUGO_RUN_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// conv is the Converter the tests call into, with the default options and
+// nothing set up: the tests set the flags (setFlag) and state they need.
+var conv = func() *Converter {
+	o := DefaultOptions()
+	return &Converter{opts: &o, logger: logger{threshold: levelDebug}}
+}()
+
+// testFlags binds the command-line flags to conv's options, for setFlag.
+var testFlags = func() *flag.FlagSet {
+	fs := flag.NewFlagSet("wordpress2hugo", flag.ContinueOnError)
+	conv.opts.RegisterFlags(fs)
+	return fs
+}()
+
+// setFlag sets a command-line flag for the duration of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := testFlags.Lookup(name)
+	if f == nil {
+		t.Fatalf("no flag -%s", name)
+	}
+	old := f.Value.String()
+	if err := testFlags.Set(name, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { testFlags.Set(name, old) })
+}
+
+func TestStripBlockComments(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"paragraph blocks",
+			"<!-- wp:paragraph -->\n<p>One</p>\n<!-- /wp:paragraph -->\n<!-- wp:paragraph {\"align\":\"center\"} -->\n<p class=\"has-text-align-center\">Two</p>\n<!-- /wp:paragraph -->",
+			"<p>One</p>\n\n\n<p class=\"has-text-align-center\">Two</p>\n",
+		},
+		{
+			"more tag kept",
+			"<!-- wp:paragraph --><p>Intro</p><!-- /wp:paragraph --><!-- wp:more --><!--more--><!-- /wp:more --><!-- wp:paragraph --><p>Rest</p><!-- /wp:paragraph -->",
+			"<p>Intro</p><!--more--><p>Rest</p>",
+		},
+		{
+			"nested blocks",
+			"<!-- wp:group --><div><!-- wp:paragraph --><p>In</p><!-- /wp:paragraph --></div><!-- /wp:group -->",
+			"<div><p>In</p></div>",
+		},
+		{
+			"other comments kept",
+			"<p>x</p><!-- a note --><!-- wp:separator --><hr/><!-- /wp:separator -->",
+			"<p>x</p><!-- a note --><hr/>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			stripBlockComments(doc)
+			got, err := doc.Find("body").Html()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBOMStripped(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, "out", dir)
+	setFlag(t, "v", "false")
+	feed := "\uFEFF" + `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel>` +
+		`<item><title>Hello</title><link>https://example.com/2024/03/05/hello/</link><guid>g1</guid>` +
+		`<description>Body</description></item></channel></rss>`
+	feedPath := filepath.Join(dir, "feed.xml")
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, err := conv.loadRSS(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rss.Channel.Items[0].Title; got != "Hello" {
+		t.Errorf("title = %q, want %q", got, "Hello")
+	}
+	rec, err := conv.processItem(rss.Channel.Items[0], time.UTC, conv.newDownloader(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsRune(string(data), '\uFEFF') {
+		t.Errorf("output contains a BOM:\n%q", data)
+	}
+	if !strings.HasPrefix(string(data), "---\ntitle: Hello\n") {
+		t.Errorf("unexpected output:\n%s", data)
+	}
+}
+
+func TestResolveNoscriptImages(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"placeholder takes the fallback",
+			`<p><img class="lazyload" src="data:image/gif;base64,R0lGOD" data-src="https://x/a.jpg" alt=""><noscript><img src="https://x/a.jpg" srcset="https://x/a-1024x768.jpg 1024w" alt="A"></noscript></p>`,
+			`<p><img class="lazyload" src="https://x/a.jpg" srcset="https://x/a-1024x768.jpg 1024w" alt="A"/></p>`,
+		},
+		{
+			"noscript-only image is unwrapped",
+			`<p><noscript><img src="https://x/b.jpg"></noscript></p>`,
+			`<p><img src="https://x/b.jpg"/></p>`,
+		},
+		{
+			"fallback for a real image is dropped",
+			`<p><img src="https://x/c.jpg"><noscript><img src="https://x/c.jpg"></noscript></p>`,
+			`<p><img src="https://x/c.jpg"/></p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resolveNoscriptImages(doc)
+			got, err := doc.Find("body").Html()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLazyImages(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"data-src",
+			`<img src="https://x/blank.gif" data-src="https://x/a.jpg">`,
+			`<img src="https://x/a.jpg"/>`,
+		},
+		{
+			"data-lazy-src and srcset",
+			`<img src="data:image/gif;base64,R0lGOD" srcset="data:image/gif;base64,R0lGOD 1w" data-lazy-src="https://x/b.jpg" data-lazy-srcset="https://x/b-300x200.jpg 300w, https://x/b.jpg 1200w">`,
+			`<img src="https://x/b.jpg" srcset="https://x/b-300x200.jpg 300w, https://x/b.jpg 1200w"/>`,
+		},
+		{
+			"data-lazy-src wins over data-orig-src",
+			`<img src="https://x/blank.gif" data-orig-src="https://x/orig.jpg" data-lazy-src="https://x/c.jpg">`,
+			`<img src="https://x/c.jpg"/>`,
+		},
+		{
+			"data-orig-src",
+			`<img src="https://x/blank.gif" data-orig-src="https://x/d.jpg">`,
+			`<img src="https://x/d.jpg"/>`,
+		},
+		{
+			"plain image untouched",
+			`<img src="https://x/e.jpg" srcset="https://x/e-300x200.jpg 300w">`,
+			`<img src="https://x/e.jpg" srcset="https://x/e-300x200.jpg 300w"/>`,
+		},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolveLazyImages(doc)
+		got, err := doc.Find("body").Html()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTrimTrackingParams(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/a?utm_source=twitter&fbclid=abc", "https://example.com/a"},
+		{"https://example.com/a?id=3&utm_medium=social&b=x#top", "https://example.com/a?id=3&b=x#top"},
+		{"/2024/03/05/post/?gclid=1&UTM_Campaign=x", "/2024/03/05/post/"},
+		{"https://example.com/search?q=utm_source", "https://example.com/search?q=utm_source"},
+		{"mailto:me@example.com", "mailto:me@example.com"},
+	}
+	for _, tt := range tests {
+		if got := conv.trimTrackingParams(tt.in); got != tt.want {
+			t.Errorf("trimTrackingParams(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripMediaParams(t *testing.T) {
+	tests := []struct{ params, in, want string }{
+		{"utm_*,fbclid,gclid,ver", "https://x/a-300x200.jpg?ver=1.2.3&utm_source=feed", "https://x/a.jpg"},
+		{"utm_*,fbclid,gclid,ver", "https://cdn.x/abc?format=jpg&ver=2", "https://cdn.x/abc?format=jpg"},
+		{"", "https://x/a.jpg?ver=1", "https://x/a.jpg?ver=1"},
+		{"w,h", "https://x/a.jpg?w=300&h=200&ver=1", "https://x/a.jpg?ver=1"},
+	}
+	for _, tt := range tests {
+		setFlag(t, "strip-media-params", tt.params)
+		if got := conv.toOriginalURL(tt.in); got != tt.want {
+			t.Errorf("-strip-media-params %q: toOriginalURL(%q) = %q, want %q", tt.params, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripMediaParamsDedup(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	setFlag(t, "static", t.TempDir())
+	setFlag(t, "v", "false")
+
+	in := `<p><img src="` + srv.URL + `/a.jpg?ver=1.2"></p><p><img src="` + srv.URL + `/a.jpg?utm_source=rss&ver=1.3"></p>`
+	dl := conv.newDownloader(2, 2)
+	html, err := conv.rewriteAndDownloadImages(in, nil, "2024-03-post", dl, &postRecord{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.Wait()
+	if len(queries) != 1 || queries[0] != "" {
+		t.Errorf("requests with queries %q, want one without", queries)
+	}
+	if n := strings.Count(html, "/media/2024-03-post/001_a.jpg"); n != 2 {
+		t.Errorf("%d references to 001_a.jpg, want 2:\n%s", n, html)
+	}
+}
+
+func TestCleanConfirmation(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel><item><title>Post</title>` +
+		`<link>https://example.com/2024/01/02/post/</link><description>Body</description></item></channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "content", "posts")
+	stale := filepath.Join(out, "stale.md")
+	args := []string{"-feed", feedPath, "-out", out, "-static", filepath.Join(dir, "static")}
+
+	tests := []struct {
+		name      string
+		extra     []string
+		wantErr   bool
+		wantStale bool
+	}{
+		{"no clean by default", nil, false, true},
+		{"foreign files need -force", []string{"-clean", "-yes"}, true, true},
+		{"no terminal refuses", []string{"-clean", "-force"}, true, true},
+		{"yes bypasses the prompt", []string{"-clean", "-force", "-yes"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.MkdirAll(out, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(stale, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			log, err := runMain(t, append(args, tt.extra...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v\n%s", err, tt.wantErr, log)
+			}
+			if got := fileExists(stale); got != tt.wantStale {
+				t.Errorf("stale file kept = %v, want %v", got, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestCleanEarlierRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link><description>a</description></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link>` +
+		`<content:encoded><![CDATA[<p><img src="` + srv.URL + `/b.png"></p>]]></content:encoded></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "content", "posts")
+	static := filepath.Join(dir, "static")
+	args := []string{"-feed", feedPath, "-out", out, "-static", static, "-report", filepath.Join(dir, "report.json")}
+	if log, err := runMain(t, append(args, "-limit", "0")...); err != nil {
+		t.Fatalf("first run: %v\n%s", err, log)
+	}
+	second := filepath.Join(out, "2024-01-second.md")
+	image := filepath.Join(static, "media", "2024-01-second", "001_b.png")
+	if !fileExists(second) || !fileExists(image) {
+		t.Fatalf("first run did not write %s and %s", second, image)
+	}
+
+	// the second run only keeps the first post: the rest of the earlier run goes
+	if log, err := runMain(t, append(args, "-limit", "1", "-clean", "-yes")...); err != nil {
+		t.Fatalf("clean run: %v\n%s", err, log)
+	} else if !strings.Contains(log, "clean: deleting 2 posts and 1 media files of an earlier run") {
+		t.Errorf("clean summary missing:\n%s", log)
+	}
+	if fileExists(second) || fileExists(image) {
+		t.Errorf("files of the earlier run were kept")
+	}
+	if _, err := os.Stat(filepath.Dir(image)); !os.IsNotExist(err) {
+		t.Errorf("empty media folder kept: %v", err)
+	}
+	if !fileExists(filepath.Join(out, "2024-02-first.md")) {
+		t.Errorf("first post missing after the clean run")
+	}
+
+	// a file written by someone else stops the clean
+	notes := filepath.Join(out, "notes.md")
+	if err := os.WriteFile(notes, []byte("mine"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	log, err := runMain(t, append(args, "-limit", "1", "-clean", "-yes")...)
+	if err == nil || !strings.Contains(log, "not written by an earlier run") {
+		t.Errorf("clean with a foreign file: err = %v\n%s", err, log)
+	}
+	if !fileExists(notes) {
+		t.Errorf("foreign file deleted")
+	}
+}
+
+func TestCleanGeneratedPages(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/"><channel>` +
+		`<wp:category><wp:category_nicename>reisen</wp:category_nicename><wp:category_parent></wp:category_parent><wp:cat_name>Reisen</wp:cat_name></wp:category>` +
+		`<wp:category><wp:category_nicename>italien</wp:category_nicename><wp:category_parent>reisen</wp:category_parent><wp:cat_name>Italien</wp:cat_name></wp:category>` +
+		`<item><title>Rom</title><link>https://example.com/2024/03/05/rom/</link><guid>g1</guid>` +
+		`<category domain="category" nicename="italien">Italien</category><description>Body</description></item></channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "content", "posts")
+	args := []string{"-feed", feedPath, "-out", out, "-static", filepath.Join(dir, "static"), "-report", filepath.Join(dir, "report.json"),
+		"-category-hierarchy", "section", "-output-index", filepath.Join(out, "archive.md")}
+	for i := 0; i < 2; i++ { // the second run finds the section indexes in place
+		if log, err := runMain(t, args...); err != nil {
+			t.Fatalf("run %d: %v\n%s", i, err, log)
+		}
+	}
+	if log, err := runMain(t, append(args, "-clean", "-yes")...); err != nil {
+		t.Fatalf("clean run: %v\n%s", err, log)
+	}
+	for _, p := range []string{"reisen/_index.md", "reisen/italien/_index.md", "reisen/italien/2024-03-rom.md", "archive.md"} {
+		if !fileExists(filepath.Join(out, p)) {
+			t.Errorf("%s missing after the clean run", p)
+		}
+	}
+
+	// a section page edited by hand is not the tool's to delete
+	idx := filepath.Join(out, "reisen", "_index.md")
+	if err := os.WriteFile(idx, []byte("---\ntitle: Travel\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runMain(t, args...)
+	if log, err := runMain(t, append(args, "-clean", "-yes")...); err == nil || !strings.Contains(log, "not written by an earlier run") {
+		t.Errorf("clean with an edited _index.md: err = %v\n%s", err, log)
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel>` +
+		`<item><title>First</title><link>https://example.com/2024/02/01/first/</link><description>new text</description></item>` +
+		`<item><title>Second</title><link>https://example.com/2024/01/15/second/</link><description>b</description></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "content", "posts")
+	edited := filepath.Join(out, "2024-02-first.md")
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(edited, []byte("edited by hand"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := runMain(t, "-feed", feedPath, "-out", out, "-static", filepath.Join(dir, "static"), "-limit", "0", "-overwrite=false")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	if !strings.Contains(log, "preserved "+edited) {
+		t.Errorf("log does not mention the preserved file:\n%s", log)
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "edited by hand" {
+		t.Errorf("edited post = %q, want it untouched", data)
+	}
+	if !fileExists(filepath.Join(out, "2024-01-second.md")) {
+		t.Errorf("new post not written")
+	}
+
+	if log, err := runMain(t, "-feed", feedPath, "-out", out, "-static", filepath.Join(dir, "static"), "-limit", "0"); err != nil {
+		t.Fatalf("%v\n%s", err, log)
+	}
+	if data, _ := os.ReadFile(edited); !strings.Contains(string(data), "new text") {
+		t.Errorf("edited post = %q, want it replaced by default", data)
+	}
+}
+
+func TestParsePubDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ in, want string }{
+		{"Fri, 15 Mar 2024 13:30:00 +0000", "2024-03-15T14:30:00+01:00"},
+		{"Fri, 15 Mar 2024 13:30:00 GMT", "2024-03-15T14:30:00+01:00"},
+		{"Fri, 15 Mar 2024 08:30:00 EST", "2024-03-15T14:30:00+01:00"},
+		{"Fri, 5 Apr 2024 08:30:00 PDT", "2024-04-05T17:30:00+02:00"},
+		{"2024-03-15T13:30:00Z", "2024-03-15T14:30:00+01:00"},
+		{"2024-03-15 14:30:00", "2024-03-15T14:30:00+01:00"},
+		{"2024-03-15T14:30:00", "2024-03-15T14:30:00+01:00"},
+		{"2024-03-15", "2024-03-15T00:00:00+01:00"},
+		{"1710509400", "2024-03-15T14:30:00+01:00"},
+	}
+	for _, tt := range tests {
+		got, err := parsePubDate(tt.in, berlin)
+		if err != nil {
+			t.Errorf("parsePubDate(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Format(time.RFC3339) != tt.want {
+			t.Errorf("parsePubDate(%q) = %s, want %s", tt.in, got.Format(time.RFC3339), tt.want)
+		}
+	}
+	if _, err := parsePubDate("15/03/2024", berlin); err == nil {
+		t.Error("unknown format parsed")
+	}
+}
+
+func TestDateSourceContentTime(t *testing.T) {
+	tests := []struct {
+		source, content string
+		want            string
+	}{
+		{"pubdate", `<p>Written <time datetime="2023-07-14T18:30:00+02:00">July 14</time></p>`, "date: 2024-03-05T10:00:00Z"},
+		{"content-time", `<p>Written <time datetime="2023-07-14T18:30:00+02:00">July 14</time></p>`, "date: 2023-07-14T16:30:00Z"},
+		{"content-time", `<p><time datetime="soon">soon</time> <time datetime="2023-07-14">July 14</time></p>`, "date: 2023-07-14T00:00:00Z"},
+		{"content-time", `<p>No time element</p>`, "date: 2024-03-05T10:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			setFlag(t, "out", t.TempDir())
+			setFlag(t, "v", "false")
+			setFlag(t, "date-source", tt.source)
+			item := Item{Title: "Post", Link: "https://example.com/2024/03/05/post/", PubDate: "Tue, 05 Mar 2024 10:00:00 +0000", ContentEncoded: tt.content}
+			rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(rec.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.want+"\n") {
+				t.Errorf("got\n%s\nwant %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripAttributes(t *testing.T) {
+	in := `<div class="entry" id="x" style="color:red" data-id="7"><p class="has-text" data-align="c">Text <a href="/a" class="link" rel="nofollow">link</a></p>` +
+		`<figure class="wp-block-gallery has-nested-images"><img src="/a.jpg" alt="A" data-id="1" style="width:10px"></figure></div>`
+	want := `<div><p>Text <a href="/a" rel="nofollow">link</a></p><figure class="wp-block-gallery"><img src="/a.jpg" alt="A"/></figure></div>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripAttributes(doc, strings.Split("class, style,id,data-*,href", ","))
+	got, err := doc.Find("body").Html()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestEmojiSlug(t *testing.T) {
+	tests := []struct {
+		mode, in, want string
+	}{
+		{"code", "Release 🎉 day", "release-u1f389-day"},
+		{"name", "Release 🎉 day", "release-party-day"},
+		{"drop", "Release 🎉 day", "release-day"},
+		{"code", "Party🎉", "partyu1f389"},
+		{"name", "Party🎉", "party-party"},
+		{"drop", "Party🎉", "party"},
+		{"name", "Odd 🧿 one", "odd-u1f9ff-one"},
+	}
+	for _, tt := range tests {
+		setFlag(t, "emoji-slug", tt.mode)
+		if got := conv.slugify(tt.in); got != tt.want {
+			t.Errorf("-emoji-slug %s: slugify(%q) = %q, want %q", tt.mode, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContentMaxImages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+	setFlag(t, "content-max-images", "2")
+
+	var in strings.Builder
+	in.WriteString("<p>Intro</p>")
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(&in, `<figure class="wp-block-image"><a href="%[1]s/p%[2]d.jpg"><img src="%[1]s/p%[2]d-300x200.jpg" alt="Photo %[2]d"></a><figcaption>Caption %[2]d</figcaption></figure>`, srv.URL, i)
+	}
+	in.WriteString("<p>Outro</p>")
+
+	dl := conv.newDownloader(2, 2)
+	html, err := conv.rewriteAndDownloadImages(in.String(), nil, "2024-03-dump", dl, &postRecord{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.Wait()
+	got, err := conv.toMarkdownPreserveOrder(html, "2024-03-dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Intro\n\n" +
+		`{{< figure src="/media/2024-03-dump/001_p1.jpg" alt="Photo 1" caption="Caption 1" >}}` + "\n\n" +
+		`{{< figure src="/media/2024-03-dump/002_p2.jpg" alt="Photo 2" caption="Caption 2" >}}` + "\n\n" +
+		"Outro\n\n" +
+		"{{< gallery >}}\n" +
+		"![Photo 3](/media/2024-03-dump/003_p3.jpg)\n" +
+		"![Photo 4](/media/2024-03-dump/004_p4.jpg)\n" +
+		"![Photo 5](/media/2024-03-dump/005_p5.jpg)\n" +
+		"{{< /gallery >}}"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	for i := 1; i <= 5; i++ {
+		if !fileExists(filepath.Join(static, "media", "2024-03-dump", fmt.Sprintf("%03d_p%d.jpg", i, i))) {
+			t.Errorf("image %d not downloaded", i)
+		}
+	}
+}
+
+func TestFlattenSingleItemLists(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"single item", `<ul><li>Just <strong>one</strong> thing</li></ul>`, `<p>Just <strong>one</strong> thing</p>`},
+		{"single item with paragraph", `<ol><li><p>Para</p></li></ol>`, `<p>Para</p>`},
+		{"multiple items", `<ol><li>First</li><li>Second</li></ol>`, `<ol><li>First</li><li>Second</li></ol>`},
+		{"nested list", `<ul><li>Outer<ul><li>Inner</li></ul></li></ul>`, `<ul><li>Outer<ul><li>Inner</li></ul></li></ul>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flattenSingleItemLists(doc)
+			got, err := doc.Find("body").Html()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDraftCategory(t *testing.T) {
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	setFlag(t, "draft-category", "Entwurf")
+	tests := []struct {
+		name string
+		cats []Category
+		want string
+	}{
+		{"in draft category", []Category{{Value: "entwurf"}, {Value: "Reisen"}}, "draft: true\n"},
+		{"published", []Category{{Value: "Reisen"}}, "draft: false\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := Item{Title: "Post", Link: "https://example.com/2024/03/05/post/", Categories: tt.cats}
+			rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(rec.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("got\n%s\nwant it to contain %q", data, tt.want)
+			}
+			if !strings.Contains(string(data), "categories:\n    - Reisen\n") || strings.Contains(strings.ToLower(string(data)), "entwurf") {
+				t.Errorf("unexpected categories:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestContentFormatHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	setFlag(t, "out", dir)
+	setFlag(t, "static", t.TempDir())
+	setFlag(t, "v", "false")
+	setFlag(t, "content-format", "html")
+
+	item := Item{Title: "Post", Link: "https://example.com/2024/03/05/post/",
+		ContentEncoded: `<p>Hello <em>world</em></p><figure><img src="` + srv.URL + `/photo-1024x768.jpg" srcset="` + srv.URL + `/photo-300x200.jpg 300w"></figure>`}
+	dl := conv.newDownloader(1, 1)
+	rec, err := conv.processItem(item, time.UTC, dl)
+	dl.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(rec.File); got != "2024-03-post.html" {
+		t.Errorf("file = %s, want 2024-03-post.html", got)
+	}
+	data, err := os.ReadFile(rec.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\n<p>Hello <em>world</em></p><figure><img src=\"/media/2024-03-post/001_photo.jpg\"/></figure>\n"
+	if !strings.HasSuffix(string(data), want) {
+		t.Errorf("got\n%s\nwant it to end with\n%s", data, want)
+	}
+}
+
+func TestRepairUTF8(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, "v", "false")
+	// "Café" with a Latin-1 é and a stray C1 byte in the description
+	feed := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><rss version=\"2.0\"><channel>" +
+		"<item><title>Caf\xe9 – süß</title><link>https://example.com/2024/03/05/cafe/</link>" +
+		"<description>Bad\x81 byte</description></item></channel></rss>"
+	feedPath := filepath.Join(dir, "feed.xml")
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, err := conv.loadRSS(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := rss.Channel.Items[0]
+	if it.Title != "Café – süß" {
+		t.Errorf("title = %q", it.Title)
+	}
+	if it.Description != "Bad byte" {
+		t.Errorf("description = %q", it.Description)
+	}
+}
+
+func TestContentField(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, "v", "false")
+	feed := `<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>` +
+		`<entry><title>Post</title><link href="https://example.com/2024/03/05/post/"/><id>p1</id><updated>2024-03-05T10:00:00Z</updated>` +
+		`<content type="html">&lt;p&gt;Teaser&lt;/p&gt;</content>` +
+		`<summary type="html">&lt;p&gt;The whole story, much longer than the teaser.&lt;/p&gt;</summary></entry></feed>`
+	feedPath := filepath.Join(dir, "feed.xml")
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ mode, want string }{
+		{"auto", "<p>Teaser</p>"},
+		{"content", "<p>Teaser</p>"},
+		{"description", "<p>The whole story, much longer than the teaser.</p>"},
+		{"longest", "<p>The whole story, much longer than the teaser.</p>"},
+	}
+	for _, tt := range tests {
+		setFlag(t, "content-field", tt.mode)
+		rss, err := conv.loadRSS(feedPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rss.Channel.Items[0].ContentEncoded; got != tt.want {
+			t.Errorf("-content-field %s: got %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestFileNamer(t *testing.T) {
+	n := newFileNamer()
+	tests := []struct{ url, want string }{
+		{"https://example.com/2024/03/beach.jpg", "beach.jpg"},
+		{"https://example.com/2024/03/beach.jpg", "beach.jpg"}, // same URL, same name
+		{"https://example.com/2023/07/beach.jpg", "beach-bd4c49db.jpg"},
+		{"https://example.com/2024/03/Strand%20am%20Meer%21.JPG", "Strand-am-Meer-fcf25ea6.JPG"},
+	}
+	for _, tt := range tests {
+		if got := n.name(tt.url); got != tt.want {
+			t.Errorf("name(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestStripImgLoadingHints(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	setFlag(t, "static", t.TempDir())
+	setFlag(t, "v", "false")
+
+	in := `<p><img src="` + srv.URL + `/a.jpg" width="10" height="20" loading="lazy" decoding="async" fetchpriority="high" sizes="100vw" alt="x"></p>`
+	dl := conv.newDownloader(1, 1)
+	html, err := conv.rewriteAndDownloadImages(in, nil, "slug", dl, &postRecord{})
+	dl.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p><img src="/media/slug/001_a.jpg" width="10" height="20" alt="x"/></p>`
+	if html != want {
+		t.Errorf("got  %s\nwant %s", html, want)
+	}
+}
+
+func TestSkipExisting(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, "fresh")
+	}))
+	defer srv.Close()
+	setFlag(t, "v", "false")
+
+	for _, skip := range []string{"true", "false"} {
+		t.Run("skip-existing="+skip, func(t *testing.T) {
+			setFlag(t, "skip-existing", skip)
+			hits.Store(0)
+			dest := filepath.Join(t.TempDir(), "001_a.jpg")
+			if err := os.WriteFile(dest, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			dl := conv.newDownloader(1, 1)
+			dl.Schedule(srv.URL+"/a.jpg", dest)
+			dl.Wait()
+			if done, err := dl.Result(srv.URL + "/a.jpg"); !done || err != nil {
+				t.Fatalf("result = %v, %v", done, err)
+			}
+			got, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, wantHits := "old", int32(0)
+			if skip == "false" {
+				want, wantHits = "fresh", 1
+			}
+			if string(got) != want || hits.Load() != wantHits {
+				t.Errorf("file = %q after %d requests, want %q after %d", got, hits.Load(), want, wantHits)
+			}
+		})
+	}
+}
+
+func TestExtensionFromContentType(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/webp; charset=binary")
+		fmt.Fprint(w, "webp")
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "static", static)
+	setFlag(t, "v", "false")
+
+	in := `<a href="` + srv.URL + `/abc123?format=webp"><img src="` + srv.URL + `/abc123?format=webp"></a>`
+	want := `<a href="/media/slug/001_abc123.webp"><img src="/media/slug/001_abc123.webp"/></a>`
+	// the second run finds the file from the first under its resolved name
+	for run := 1; run <= 2; run++ {
+		dl := conv.newDownloader(1, 1)
+		rec := &postRecord{}
+		html, err := conv.rewriteAndDownloadImages(in, nil, "slug", dl, rec)
+		dl.Wait()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if html != want {
+			t.Errorf("run %d: got  %s\nwant %s", run, html, want)
+		}
+		if len(rec.Assets) != 1 || filepath.Base(rec.Assets[0].Dest) != "001_abc123.webp" {
+			t.Errorf("run %d: assets = %+v", run, rec.Assets)
+		}
+	}
+	if got, err := os.ReadFile(filepath.Join(static, "media", "slug", "001_abc123.webp")); err != nil || string(got) != "webp" {
+		t.Errorf("file = %q, %v", got, err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("%d requests, want 1", hits.Load())
+	}
+}
+
+func TestSlugifyTransliteration(t *testing.T) {
+	tests := map[string]string{
+		"Müller über Ötzi":       "mueller-ueber-oetzi",
+		"Straße":                 "strasse",
+		"Café crème à la Señora": "cafe-creme-a-la-senora",
+		"Łódź & Ærø":             "lodz---aero",
+		"Привет Welt":            "welt",
+	}
+	for in, want := range tests {
+		if got := conv.slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDraftFromStatus(t *testing.T) {
+	setFlag(t, "out", t.TempDir())
+	setFlag(t, "v", "false")
+	tests := []struct {
+		status, allDrafts, want string
+	}{
+		{"publish", "false", "draft: false\n"},
+		{"", "false", "draft: false\n"},
+		{"draft", "false", "draft: true\n"},
+		{"pending", "false", "draft: true\n"},
+		{"future", "false", "draft: true\n"},
+		{"private", "false", "draft: true\n"},
+		{"publish", "true", "draft: true\n"},
+		{"", "true", "draft: true\n"},
+	}
+	for _, tt := range tests {
+		setFlag(t, "all-drafts", tt.allDrafts)
+		item := Item{Title: "Post", Link: "https://example.com/2024/03/05/post/", Status: tt.status}
+		rec, err := conv.processItem(item, time.UTC, conv.newDownloader(1, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("status %q, -all-drafts=%s: got\n%s\nwant it to contain %q", tt.status, tt.allDrafts, data, tt.want)
+		}
+	}
+}
+
+func TestMaxImageBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		switch r.URL.Path {
+		case "/big.png":
+			fmt.Fprint(w, strings.Repeat("x", 100))
+		case "/chunked.png": // no Content-Length, stopped mid-copy
+			for i := 0; i < 10; i++ {
+				fmt.Fprint(w, strings.Repeat("x", 10))
+				w.(http.Flusher).Flush()
+			}
+		default:
+			fmt.Fprint(w, "small")
+		}
+	}))
+	defer srv.Close()
+	static := t.TempDir()
+	setFlag(t, "static", static)
+	setFlag(t, "max-image-bytes", "50")
+	setFlag(t, "retries", "1")
+	setFlag(t, "v", "false")
+
+	in := `<img src="` + srv.URL + `/big.png"><img src="` + srv.URL + `/chunked.png"><img src="` + srv.URL + `/small.png">`
+	want := `<img src="` + srv.URL + `/big.png"/><img src="` + srv.URL + `/chunked.png"/><img src="/media/slug/003_small.png"/>`
+	dl := conv.newDownloader(1, 1)
+	rec := &postRecord{}
+	html, err := conv.rewriteAndDownloadImages(in, nil, "slug", dl, rec)
+	dl.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html != want {
+		t.Errorf("got  %s\nwant %s", html, want)
+	}
+	if len(rec.Assets) != 1 {
+		t.Errorf("assets = %+v", rec.Assets)
+	}
+	if f := dl.Failures(); len(f) != 0 {
+		t.Errorf("skipped images reported as failures: %+v", f)
+	}
+	files, _ := filepath.Glob(filepath.Join(static, "media", "slug", "*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "003_small.png" {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestHTMLUnescape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"News &raquo; Archiv", "News » Archiv"},
+		{"Q&amp;A &mdash; part&nbsp;2", "Q&A — part 2"},
+		{"Don&rsquo;t &#8211; &#x2014;", "Don’t – —"},
+		{"&amp;amp; stays &amp;", "&amp; stays &"}, // one level only
+		{"AT&T & co", "AT&T & co"},
+	}
+	for _, tt := range tests {
+		if got := htmlUnescape(tt.in); got != tt.want {
+			t.Errorf("htmlUnescape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	tags, cats := conv.splitTagsAndCategories([]Category{{Value: "Reisen &raquo; Berlin"}, {Value: "Rock &amp; Roll", Domain: "post_tag"}})
+	if len(cats) != 1 || cats[0] != "Reisen » Berlin" || len(tags) != 1 || tags[0] != "Rock & Roll" {
+		t.Errorf("tags = %q, categories = %q", tags, cats)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+		fmt.Fprint(w, "late")
+	}))
+	defer srv.Close()
+	setFlag(t, "v", "false")
+	setFlag(t, "retries", "1")
+
+	if got := conv.downloadTimeout(); got != 120*time.Second {
+		t.Errorf("default download timeout = %v, want -timeout's 120s", got)
+	}
+	setFlag(t, "download-timeout", "200ms")
+	start := time.Now()
+	if _, _, err := conv.downloadFile(srv.URL+"/a.jpg", filepath.Join(t.TempDir(), "a.jpg")); err == nil {
+		t.Error("slow download succeeded despite -download-timeout 200ms")
+	}
+	setFlag(t, "feed-timeout", "200ms")
+	if _, err := conv.loadRSS(srv.URL + "/feed/"); err == nil {
+		t.Error("slow feed loaded despite -feed-timeout 200ms")
+	}
+	if d := time.Since(start); d > 900*time.Millisecond {
+		t.Errorf("timeouts took %v", d)
+	}
+}
+
+func TestTrimLinkParams(t *testing.T) {
+	setFlag(t, "trim-utm", "true")
+	in := `<p><a href="https://example.com/shop?id=7&utm_campaign=x&PHPSESSID=abc&mc_cid=1#top">shop</a> ` +
+		`<a href="https://example.com/?utm_source=feed">home</a></p>`
+
+	tests := []struct {
+		params string
+		want   []string
+	}{
+		{"utm_*,fbclid,gclid", []string{`href="https://example.com/shop?id=7&amp;PHPSESSID=abc&amp;mc_cid=1#top"`, `href="https://example.com/"`}},
+		{"utm_*,mc_*,phpsessid", []string{`href="https://example.com/shop?id=7#top"`, `href="https://example.com/"`}},
+	}
+	for _, tt := range tests {
+		setFlag(t, "strip-link-params", tt.params)
+		out, err := conv.rewriteAndDownloadImages(in, nil, "slug", conv.newDownloader(1, 1), &postRecord{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("-strip-link-params %s: output lacks %s:\n%s", tt.params, want, out)
+			}
+		}
+	}
+}
+
+// The cleaned links must survive into the written Markdown, not only the HTML
+// the converter reads.
+func TestTrimLinkParamsMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	feedPath := filepath.Join(dir, "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>Links</title><link>https://example.com/2024/03/links/</link><pubDate>Fri, 01 Mar 2024 10:00:00 +0000</pubDate><content:encoded><![CDATA[` +
+		`<p>Visit the <a href="https://example.com/shop?id=7&utm_campaign=x&mc_cid=1#top">shop</a> ` +
+		`or go <a href="https://example.com/?utm_source=feed">home</a>.</p>` +
+		`]]></content:encoded></item></channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"default", nil, []string{"[shop](https://example.com/shop?id=7&mc_cid=1#top)", "[home](https://example.com/)"}},
+		{"custom", []string{"-strip-link-params", "utm_*,mc_*"}, []string{"[shop](https://example.com/shop?id=7#top)", "[home](https://example.com/)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := filepath.Join(dir, tt.name)
+			out := filepath.Join(base, "content", "posts")
+			args := append([]string{"-feed", feedPath, "-out", out, "-static", filepath.Join(base, "static"), "-limit", "0", "-trim-utm"}, tt.args...)
+			log, err := runMain(t, args...)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, log)
+			}
+			data, err := os.ReadFile(filepath.Join(out, "2024-03-links.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("output lacks %s:\n%s", want, data)
+				}
+			}
+			if strings.Contains(string(data), "utm_") {
+				t.Errorf("tracking parameter left in output:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestLinkedFullSizeImage(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "jpg")
+	}))
+	defer srv.Close()
+	setFlag(t, "v", "false")
+	static := t.TempDir()
+	setFlag(t, "static", static)
+
+	in := `<figure><a href="` + srv.URL + `/uploads/photo-full.jpg"><img src="` + srv.URL + `/uploads/thumb-150x150.jpg"></a></figure>` +
+		`<p><a href="` + srv.URL + `/uploads/b-1024x768.jpg"><img src="` + srv.URL + `/uploads/b-300x200.jpg"></a></p>`
+	dl := conv.newDownloader(2, 2)
+	rec := &postRecord{}
+	out, err := conv.rewriteAndDownloadImages(in, nil, "slug", dl, rec)
+	dl.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<a href="/media/slug/002_photo-full.jpg"><img src="/media/slug/001_thumb.jpg"/></a>`,
+		`<a href="/media/slug/003_b.jpg"><img src="/media/slug/003_b.jpg"/></a>`, // same original: one file
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s:\n%s", want, out)
+		}
+	}
+	if !fileExists(filepath.Join(static, "media", "slug", "002_photo-full.jpg")) {
+		t.Errorf("full-size image not downloaded (requests: %v)", paths)
+	}
+	if len(rec.Assets) != 3 {
+		t.Errorf("assets = %+v, want 3", rec.Assets)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+	o := DefaultOptions()
+	o.Out = filepath.Join(t.TempDir(), "posts")
+	o.Static = t.TempDir()
+	o.Timezone = "UTC"
+	o.TitleCase = "title"
+	o.Verbose = false
+	item := Item{
+		Title:          "hello world",
+		Link:           "https://example.com/2024/03/hello/",
+		PubDate:        "Tue, 05 Mar 2024 10:00:00 +0000",
+		ContentEncoded: `<p>Body text</p><p><img src="` + srv.URL + `/a.png" alt="A"></p>`,
+	}
+	fm, body, err := Convert(item, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fm.Title != "Hello World" || fm.Slug != "hello" {
+		t.Errorf("front matter = %+v", fm)
+	}
+	if !strings.Contains(body, "Body text") || !strings.Contains(body, "![A](/media/2024-03-hello/001_a.png)") {
+		t.Errorf("body = %q", body)
+	}
+	if _, err := os.Stat(filepath.Join(o.Static, "media", "2024-03-hello", "001_a.png")); err != nil {
+		t.Errorf("image not downloaded: %v", err)
+	}
+	if _, err := os.Stat(o.Out); !os.IsNotExist(err) {
+		t.Errorf("Convert wrote to -out: %v", err)
+	}
+
+	o.Source = "ftp"
+	if _, _, err := Convert(item, o); err == nil || !strings.Contains(err.Error(), "-source") {
+		t.Errorf("invalid options: err = %v", err)
+	}
+}
+
+func TestConvertersSideBySide(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+	feedPath := filepath.Join(t.TempDir(), "feed.xml")
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>` +
+		`<item><title>hello world</title><link>https://example.com/2024/03/hello/</link><pubDate>Tue, 05 Mar 2024 10:00:00 +0000</pubDate>` +
+		`<content:encoded><![CDATA[<p><img src="` + srv.URL + `/a.png"></p>]]></content:encoded></item>` +
+		`</channel></rss>`
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	titles := map[string]string{"none": "hello world", "title": "Hello World", "sentence": "Hello world"}
+	var wg sync.WaitGroup
+	for titleCase, want := range titles {
+		o := DefaultOptions()
+		o.Feed = feedPath
+		o.Static = t.TempDir()
+		o.TitleCase = titleCase
+		o.Rate, o.GlobalRate = 100, 100
+		o.Verbose = false
+		c, err := NewConverter(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items, err := c.LoadItems()
+			if err != nil || len(items) != 1 {
+				t.Errorf("%s: %d items, %v", titleCase, len(items), err)
+				return
+			}
+			fm, _, err := c.Convert(items[0])
+			if err != nil || fm.Title != want {
+				t.Errorf("%s: title %q, %v; want %q", titleCase, fm.Title, err, want)
+			}
+			if !fileExists(filepath.Join(o.Static, "media", "2024-03-hello", "001_a.png")) {
+				t.Errorf("%s: image not downloaded below its own -static", titleCase)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadWPREST(t *testing.T) {
+	pages := []string{
+		`[{"date_gmt":"2024-03-05T10:00:00","slug":"first-post","link":"https://example.com/2024/03/05/first-post/",` +
+			`"guid":{"rendered":"https://example.com/?p=1"},"title":{"rendered":"First &amp; best"},"content":{"rendered":"<p>Full text</p>"},` +
+			`"_embedded":{"author":[{"name":"Klaus"}],"wp:term":[[{"name":"Reisen","taxonomy":"category"}],[{"name":"rom","taxonomy":"post_tag"}]]}}]`,
+		`[{"date_gmt":"2024-01-02T08:30:00","slug":"second","link":"https://example.com/2024/01/02/second/",` +
+			`"guid":{"rendered":"https://example.com/?p=2"},"title":{"rendered":"Second"},"content":{"rendered":"<p>More</p>"}}]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" || r.URL.Query().Get("_embed") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		var page int
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		if page < 1 || page > len(pages) {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-WP-TotalPages", fmt.Sprint(len(pages)))
+		fmt.Fprint(w, pages[page-1])
+	}))
+	defer srv.Close()
+	setFlag(t, "v", "false")
+
+	rss, err := conv.loadWPREST(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rss.Channel.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(rss.Channel.Items))
+	}
+	it := rss.Channel.Items[0]
+	if normalizeTitle(it.Title, "none") != "First & best" || it.PostName != "first-post" || it.GUID != "https://example.com/?p=1" ||
+		it.PubDate != "Tue, 05 Mar 2024 10:00:00 +0000" || it.ContentEncoded != "<p>Full text</p>" || it.Creator != "Klaus" {
+		t.Errorf("unexpected item: %+v", it)
+	}
+	tags, cats := conv.splitTagsAndCategories(it.Categories)
+	if fmt.Sprint(tags) != "[rom]" || fmt.Sprint(cats) != "[Reisen]" {
+		t.Errorf("tags = %v, categories = %v", tags, cats)
+	}
+	if got := rss.Channel.Items[1].Link; got != "https://example.com/2024/01/02/second/" {
+		t.Errorf("second page link = %s", got)
+	}
+}
@@ -0,0 +1,159 @@
+package wordpress2hugo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Raw XML pass: gofeed drops WordPress-specific elements (wp:post_name etc.),
+// so the feed bytes are additionally decoded with encoding/xml into the RSS
+// structs and the extra fields are merged into the gofeed-derived items.
+// Field tags without a namespace match any namespace, which conveniently
+// covers the different WXR versions (export/1.0, 1.1, 1.2).
+
+func decodeRawRSS(data []byte) (*RSS, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(label) {
+		case "utf-8", "utf8", "us-ascii", "ascii":
+			return input, nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	var raw RSS
+	if err := d.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// markPostFormat tags the item's post format pseudo-category (which gofeed
+// delivers as a plain category like "Gallery") with its domain and nicename.
+func markPostFormat(it, raw *Item) {
+	for _, rc := range raw.Categories {
+		if rc.Domain != "post_format" {
+			continue
+		}
+		for i := range it.Categories {
+			if c := &it.Categories[i]; c.Domain == "" && strings.TrimSpace(c.Value) == strings.TrimSpace(rc.Value) {
+				c.Domain, c.Nicename = rc.Domain, rc.Nicename
+				break
+			}
+		}
+	}
+}
+
+// mergeRawXML copies the WordPress fields of the raw items onto the matching
+// parsed items (by GUID, falling back to the link), including the hierarchy of
+// their nested categories.
+func mergeRawXML(out *RSS, raw *RSS) {
+	byKey := make(map[string]*Item, 2*len(raw.Channel.Items))
+	for i := range raw.Channel.Items {
+		ri := &raw.Channel.Items[i]
+		if g := strings.TrimSpace(ri.GUID); g != "" {
+			byKey[g] = ri
+		}
+		if l := strings.TrimSpace(ri.Link); l != "" {
+			byKey[l] = ri
+		}
+	}
+	paths := categoryPaths(raw.Channel.Categories)
+	for i := range out.Channel.Items {
+		it := &out.Channel.Items[i]
+		ri, ok := byKey[strings.TrimSpace(it.GUID)]
+		if !ok {
+			ri, ok = byKey[strings.TrimSpace(it.Link)]
+		}
+		if !ok {
+			continue
+		}
+		it.PostName = strings.TrimSpace(ri.PostName)
+		it.PostDate = strings.TrimSpace(ri.PostDate)
+		it.PostDateGMT = strings.TrimSpace(ri.PostDateGMT)
+		it.Status = strings.TrimSpace(ri.Status)
+		it.PostType = strings.TrimSpace(ri.PostType)
+		it.PostModified = strings.TrimSpace(ri.PostModified)
+		it.PostModifiedGMT = strings.TrimSpace(ri.PostModifiedGMT)
+		it.Excerpt = strings.TrimSpace(ri.Excerpt)
+		markPostFormat(it, ri)
+		for _, c := range it.Categories {
+			name := strings.TrimSpace(htmlUnescape(c.Value))
+			if p := paths[name]; len(p) > 1 {
+				if it.CategoryPaths == nil {
+					it.CategoryPaths = make(map[string][]string)
+				}
+				it.CategoryPaths[name] = p
+			}
+		}
+	}
+}
+
+// wxrSkippedTypes are WXR post types that are not content: media library
+// entries, menus, revisions and block editor internals.
+var wxrSkippedTypes = map[string]bool{
+	"attachment": true, "nav_menu_item": true, "revision": true, "custom_css": true,
+	"customize_changeset": true, "oembed_cache": true, "user_request": true,
+	"wp_block": true, "wp_template": true, "wp_template_part": true,
+	"wp_global_styles": true, "wp_navigation": true, "wp_font_family": true, "wp_font_face": true,
+}
+
+// wxrContentItems drops the WXR items that are not posts or pages (see
+// wxrSkippedTypes) and trashed posts. Plain feeds have no post type and pass.
+func (c *Converter) wxrContentItems(items []Item) []Item {
+	out := items[:0]
+	skipped := 0
+	for _, it := range items {
+		if wxrSkippedTypes[it.PostType] || it.Status == "trash" {
+			skipped++
+			continue
+		}
+		out = append(out, it)
+	}
+	if skipped > 0 {
+		c.debugf("skipped %d export items that are not posts (attachments, menus, trash, …)", skipped)
+	}
+	return out
+}
+
+// wxrDraft reports whether a wp:status means the post was not published
+// (draft, pending, private, future, …). Items without a status are published.
+func wxrDraft(status string) bool {
+	return status != "" && status != "publish"
+}
+
+// wxrDateLayout is the format of wp:post_date and wp:post_date_gmt.
+const wxrDateLayout = "2006-01-02 15:04:05"
+
+// itemTime is the post date: wp:post_date_gmt, else wp:post_date (blog-local,
+// read in loc) for WXR exports, the pubDate otherwise.
+func itemTime(item Item, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(wxrDateLayout, item.PostDateGMT); err == nil && t.Year() > 1 {
+		return t.In(loc), nil
+	}
+	if t, err := time.ParseInLocation(wxrDateLayout, item.PostDate, loc); err == nil && t.Year() > 1 {
+		return t, nil
+	}
+	return parsePubDate(item.PubDate, loc)
+}
+
+// itemLastMod is the modification time: wp:post_modified_gmt, else
+// wp:post_modified (read in loc), else the feed's updated time. Zero when
+// unknown.
+func itemLastMod(item Item, loc *time.Location) time.Time {
+	if t, err := time.Parse(wxrDateLayout, item.PostModifiedGMT); err == nil && t.Year() > 1 {
+		return t.In(loc)
+	}
+	if t, err := time.ParseInLocation(wxrDateLayout, item.PostModified, loc); err == nil && t.Year() > 1 {
+		return t
+	}
+	if t, err := time.Parse(time.RFC3339, item.Updated); err == nil {
+		return t.In(loc)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,104 @@
+package wordpress2hugo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostNameSlug(t *testing.T) {
+	tests := []struct {
+		name, link, postName, pubDate string
+		want                          string
+	}{
+		{"post_name wins over the link", "https://example.com/2024/03/05/old-link/", "the-real-slug", "", "2024-03-the-real-slug.md"},
+		{"percent-encoded post_name", "https://example.com/2024/03/05/x/", "summer%2d2024", "", "2024-03-summer-2024.md"},
+		{"date from pubDate for a ?p= link", "https://example.com/?p=12", "draft-post", "Tue, 05 Mar 2024 10:00:00 +0000", "2024-03-draft-post.md"},
+		{"no post_name keeps the link", "https://example.com/2024/03/05/from-link/", "", "", "2024-03-from-link.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			setFlag(t, "out", dir)
+			setFlag(t, "v", "false")
+			feed := fmt.Sprintf(`<?xml version="1.0"?><rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/"><channel>`+
+				`<item><title>Title</title><link>%s</link><guid>g1</guid><pubDate>%s</pubDate><wp:post_name>%s</wp:post_name>`+
+				`<description>Body</description></item></channel></rss>`, tt.link, tt.pubDate, tt.postName)
+			feedPath := filepath.Join(dir, "feed.xml")
+			if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			rss, err := conv.loadRSS(feedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := conv.processItem(rss.Channel.Items[0], time.UTC, conv.newDownloader(1, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filepath.Base(rec.File); got != tt.want {
+				t.Errorf("file = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWXRExport(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, "out", dir)
+	setFlag(t, "v", "false")
+	item := func(title, postName, date, dateGMT, status, postType string) string {
+		return fmt.Sprintf(`<item><title>%[1]s</title><link>https://example.com/?p=%[2]s</link><guid>g-%[2]s</guid>`+
+			`<pubDate>Mon, 30 Nov -0001 00:00:00 +0000</pubDate><content:encoded><![CDATA[<p>Body</p>]]></content:encoded>`+
+			`<wp:post_name>%[2]s</wp:post_name><wp:post_date>%[3]s</wp:post_date><wp:post_date_gmt>%[4]s</wp:post_date_gmt>`+
+			`<wp:status>%[5]s</wp:status><wp:post_type>%[6]s</wp:post_type></item>`, title, postName, date, dateGMT, status, postType)
+	}
+	feed := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/"><channel>` +
+		item("Published", "published", "2023-12-31 23:30:00", "2023-12-31 22:30:00", "publish", "post") +
+		item("Photo", "photo", "2023-12-01 10:00:00", "2023-12-01 09:00:00", "inherit", "attachment") +
+		item("Unfinished", "unfinished", "2024-02-10 08:00:00", "0000-00-00 00:00:00", "draft", "post") +
+		item("Deleted", "deleted", "2024-01-05 08:00:00", "2024-01-05 07:00:00", "trash", "post") +
+		item("Menu", "menu", "2024-01-05 08:00:00", "2024-01-05 07:00:00", "publish", "nav_menu_item") +
+		`</channel></rss>`
+	feedPath := filepath.Join(dir, "export.xml")
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, err := conv.loadRSS(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rss.Channel.Items) != 2 {
+		t.Fatalf("got %d items, want the 2 posts", len(rss.Channel.Items))
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		file, date string
+		draft      bool
+	}{
+		{"2023-12-published.md", "date: 2023-12-31T23:30:00+01:00", false},
+		{"2024-02-unfinished.md", "date: 2024-02-10T08:00:00+01:00", true},
+	}
+	for i, tt := range tests {
+		rec, err := conv.processItem(rss.Channel.Items[i], berlin, conv.newDownloader(1, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filepath.Base(rec.File); got != tt.file {
+			t.Errorf("file = %s, want %s", got, tt.file)
+		}
+		data, err := os.ReadFile(rec.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), tt.date+"\n") || !strings.Contains(string(data), fmt.Sprintf("draft: %v\n", tt.draft)) {
+			t.Errorf("%s: want %s and draft: %v in\n%s", tt.file, tt.date, tt.draft, data)
+		}
+	}
+}